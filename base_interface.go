@@ -0,0 +1,96 @@
+package struct2interface
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"strconv"
+	"strings"
+)
+
+// baseInterface returns the BaseInterface embedded by every interface as
+// written in the interface files, such as yaml.Marshaler for
+// "gopkg.in/yaml.v3.Marshaler", and the import it needs, if any.
+func (o Options) baseInterface() (string, string, error) {
+	if o.BaseInterface == "" {
+		return "", "", nil
+	}
+	var (
+		typ     = o.BaseInterface
+		imp     string
+		args    string
+		lastDir = strings.LastIndex(typ, "/") + 1
+	)
+	if i := strings.Index(typ[lastDir:], "["); i >= 0 {
+		typ, args = typ[:lastDir+i], typ[lastDir+i:]
+	}
+	if i := strings.LastIndex(typ[lastDir:], "."); i >= 0 {
+		path := typ[:lastDir+i]
+		typ = assumedPackageName(path) + typ[lastDir+i:]
+		imp = strconv.Quote(path)
+	}
+	if err := checkBaseInterface(typ + args); err != nil {
+		return "", "", fmt.Errorf("struct2interface: BaseInterface %s: %w", o.BaseInterface, err)
+	}
+	// the generated implementations only have the struct methods
+	if g := o.implementation(); g != "" {
+		return "", "", fmt.Errorf("struct2interface: BaseInterface cannot be combined with %s", g)
+	}
+	return typ + args, imp, nil
+}
+
+// implementation returns the first option generating an implementation of
+// the interfaces, such as GenTestMock, or "" if none is set.
+func (o Options) implementation() string {
+	for _, g := range []struct {
+		name string
+		set  bool
+	}{
+		{"GenTestMock", o.GenTestMock},
+		{"GenCircuitBreaker", o.GenCircuitBreaker},
+		{"GenRetry", o.GenRetry},
+		{"GenOTelTracer", o.GenOTelTracer},
+		{"GenCache", o.GenCache},
+		{"GenTimeoutWrapper", o.GenTimeoutWrapper},
+		{"GenChangeDetector", o.GenChangeDetector},
+	} {
+		if g.set {
+			return g.name
+		}
+	}
+	return ""
+}
+
+// checkBaseInterface verifies that typ is a possibly qualified and
+// instantiated type name.
+func checkBaseInterface(typ string) error {
+	expr, err := parser.ParseExpr(typ)
+	if err != nil {
+		return err
+	}
+	switch e := expr.(type) {
+	case *ast.IndexExpr:
+		expr = e.X
+	case *ast.IndexListExpr:
+		expr = e.X
+	}
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return nil
+	case *ast.SelectorExpr:
+		if _, ok := e.X.(*ast.Ident); ok {
+			return nil
+		}
+	}
+	return errors.New("not a type name")
+}
+
+// embedBase prepends base to the methods of the interface iface, unless it
+// is iface itself.
+func embedBase(methods []string, iface, base string) []string {
+	if base == "" || base == iface {
+		return methods
+	}
+	return append([]string{base}, methods...)
+}
@@ -0,0 +1,69 @@
+package struct2interface
+
+import (
+	"fmt"
+	"strings"
+)
+
+// isStructType reports whether structName is a struct type, which makeBuilder
+// and makeFunctionalOptions need to allocate it. Types declared in files
+// without methods are missing from declared and assumed to be structs.
+func isStructType(structName string, fields map[string][]Field, declared map[string]string) bool {
+	if _, ok := fields[structName]; ok {
+		return true
+	}
+	_, ok := declared[structName]
+	return !ok
+}
+
+// makeBuilder appends a <Struct>Builder type that sets the fields of a new
+// struct one by one and returns it as the generated interface.
+func makeBuilder(output []string, structName string, fields []Field, opts Options) []string {
+	var (
+		builder = structName + "Builder"
+		iface   = opts.interfaceName(structName)
+		seen    = make(map[string]struct{})
+	)
+
+	output = append(output,
+		"",
+		fmt.Sprintf("// %s builds a %s field by field.", builder, structName),
+		fmt.Sprintf("type %s struct {", builder),
+		fmt.Sprintf("v *%s", structName),
+		"}",
+		"",
+		fmt.Sprintf("// New%s returns a builder for a zero %s.", builder, structName),
+		fmt.Sprintf("func New%s() *%s {", builder, builder),
+		fmt.Sprintf("return &%s{v: &%s{}}", builder, structName),
+		"}",
+	)
+
+	for _, f := range fields {
+		if f.Name == "_" {
+			continue
+		}
+		setter := "With" + strings.ToUpper(f.Name[:1]) + f.Name[1:]
+		if _, ok := seen[setter]; ok {
+			continue
+		}
+		seen[setter] = struct{}{}
+
+		output = append(output,
+			"",
+			fmt.Sprintf("// %s sets %s.", setter, f.Name),
+			fmt.Sprintf("func (b *%s) %s(v %s) *%s {", builder, setter, f.Type, builder),
+			fmt.Sprintf("b.v.%s = v", f.Name),
+			"return b",
+			"}",
+		)
+	}
+
+	output = append(output,
+		"",
+		fmt.Sprintf("// Build returns the built %s as a %s.", structName, iface),
+		fmt.Sprintf("func (b *%s) Build() %s {", builder, iface),
+		"return b.v",
+		"}",
+	)
+	return output
+}
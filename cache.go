@@ -0,0 +1,123 @@
+package struct2interface
+
+import (
+	"fmt"
+	"strings"
+)
+
+// makeCache appends a <Struct>Cache wrapping the interface. Successful calls
+// of the methods named with a cache prefix are memoized by method and
+// arguments, context.Context ones excluded; calls of the other methods clear
+// the cache once they return.
+func makeCache(output []string, structName string, methods []Method, opts Options) []string {
+	var (
+		iface = opts.interfaceName(structName)
+		cache = structName + "Cache"
+	)
+
+	output = append(output,
+		"",
+		fmt.Sprintf("// %s wraps a %s, memoizing the results of its read methods", cache, iface),
+		"// until any other method is called.",
+		fmt.Sprintf("type %s struct {", cache),
+		fmt.Sprintf("impl %s", iface),
+		"cache sync.Map",
+		"}",
+		"",
+		fmt.Sprintf("var _ %s = (*%s)(nil)", iface, cache),
+		"",
+		fmt.Sprintf("// New%s returns impl with its read methods memoized.", cache),
+		fmt.Sprintf("func New%s(impl %s) *%s {", cache, iface, cache),
+		fmt.Sprintf("return &%s{impl: impl}", cache),
+		"}",
+		"",
+		fmt.Sprintf("func (w *%s) invalidate() {", cache),
+		"w.cache.Range(func(key, _ interface{}) bool {",
+		"w.cache.Delete(key)",
+		"return true",
+		"})",
+		"}",
+	)
+
+	for _, m := range methods {
+		head, args := wrapperMethod(cache, m, "key")
+		call := fmt.Sprintf("w.impl.%s(%s)", m.Name, args)
+		output = append(output, "", head)
+		if !cached(m, opts) {
+			if len(m.Results) == 0 {
+				output = append(output, call, "w.invalidate()", "}")
+				continue
+			}
+			results := strings.Join(resultVars(m.Results), ", ")
+			output = append(output,
+				fmt.Sprintf("%s := %s", results, call),
+				"w.invalidate()",
+				fmt.Sprintf("return %s", results),
+				"}",
+			)
+			continue
+		}
+
+		var (
+			results = resultVars(m.Results)
+			loads   = make([]string, len(results))
+			stored  = "w.cache.Store(key, []interface{}{" + strings.Join(results, ", ") + "})"
+		)
+		for i, r := range m.Results {
+			loads[i] = fmt.Sprintf("%s, _ := rs[%d].(%s)", results[i], i, r.Type)
+		}
+		output = append(output,
+			fmt.Sprintf("key := fmt.Sprintf(%q, []interface{}{%s})", m.Name+"%#v", strings.Join(cacheKeyArgs(m.Params), ", ")),
+			"if v, ok := w.cache.Load(key); ok {",
+			"rs := v.([]interface{})",
+		)
+		output = append(output, loads...)
+		output = append(output,
+			fmt.Sprintf("return %s", strings.Join(results, ", ")),
+			"}",
+			fmt.Sprintf("%s := %s", strings.Join(results, ", "), call),
+		)
+		if returnsError(m) {
+			output = append(output,
+				fmt.Sprintf("if %s == nil {", results[len(results)-1]),
+				stored,
+				"}",
+			)
+		} else {
+			output = append(output, stored)
+		}
+		output = append(output,
+			fmt.Sprintf("return %s", strings.Join(results, ", ")),
+			"}",
+		)
+	}
+	return output
+}
+
+// cached reports whether the results of m are memoized: it must be named
+// with a cache prefix and return something.
+func cached(m Method, opts Options) bool {
+	if len(m.Results) == 0 {
+		return false
+	}
+	for _, prefix := range opts.cachePrefixes() {
+		if strings.HasPrefix(m.Name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// cacheKeyArgs returns the arguments identifying a call of a method with
+// params, leaving out contexts.
+func cacheKeyArgs(params []Param) []string {
+	_, args := paramList(params, "key")
+	var keys []string
+	for i, p := range params {
+		if p.Type == "context.Context" {
+			continue
+		}
+		keys = append(keys, strings.TrimSuffix(args[i], "..."))
+	}
+	return keys
+}
@@ -0,0 +1,89 @@
+package struct2interface
+
+import (
+	"fmt"
+	"strings"
+)
+
+// makeChangeDetector appends a <Struct>ChangeDetector wrapping the
+// interface. The values returned by every method, the error aside, are
+// compared to those of its previous successful call and OnChange is called
+// with both when they differ.
+func makeChangeDetector(output []string, structName string, methods []Method, opts Options) []string {
+	var (
+		iface    = opts.interfaceName(structName)
+		detector = structName + "ChangeDetector"
+	)
+
+	output = append(output,
+		"",
+		fmt.Sprintf("// %s wraps a %s, calling OnChange when a method returns", detector, iface),
+		"// values different from those of its previous successful call.",
+		fmt.Sprintf("type %s struct {", detector),
+		fmt.Sprintf("impl %s", iface),
+		"// OnChange is called with the method name and its previous and new",
+		"// values, in a []interface{} for methods returning several.",
+		"OnChange func(methodName string, oldVal, newVal interface{})",
+		"mu sync.Mutex",
+		"last map[string]interface{}",
+		"}",
+		"",
+		fmt.Sprintf("var _ %s = (*%s)(nil)", iface, detector),
+		"",
+		fmt.Sprintf("// New%s returns impl reporting the changes of its values to", detector),
+		"// onChange.",
+		fmt.Sprintf("func New%s(impl %s, onChange func(methodName string, oldVal, newVal interface{})) *%s {", detector, iface, detector),
+		fmt.Sprintf("return &%s{impl: impl, OnChange: onChange, last: make(map[string]interface{})}", detector),
+		"}",
+		"",
+		"// observe records newVal as the last value of methodName, calling",
+		"// OnChange unless it is the first one or equal to the previous one.",
+		fmt.Sprintf("func (w *%s) observe(methodName string, newVal interface{}) {", detector),
+		"w.mu.Lock()",
+		"oldVal, seen := w.last[methodName]",
+		"w.last[methodName] = newVal",
+		"w.mu.Unlock()",
+		"if seen && w.OnChange != nil && !reflect.DeepEqual(oldVal, newVal) {",
+		"w.OnChange(methodName, oldVal, newVal)",
+		"}",
+		"}",
+	)
+
+	for _, m := range methods {
+		head, args := wrapperMethod(detector, m)
+		call := fmt.Sprintf("w.impl.%s(%s)", m.Name, args)
+		output = append(output, "", head)
+		values := resultVars(m.Results)
+		if returnsError(m) {
+			values = values[:len(values)-1]
+		}
+		if len(values) == 0 {
+			output = append(output, passThrough(m, call), "}")
+			continue
+		}
+
+		var (
+			results = strings.Join(resultVars(m.Results), ", ")
+			newVal  = values[0]
+		)
+		if len(values) > 1 {
+			newVal = "[]interface{}{" + strings.Join(values, ", ") + "}"
+		}
+		observed := fmt.Sprintf("w.observe(%q, %s)", m.Name, newVal)
+		output = append(output, fmt.Sprintf("%s := %s", results, call))
+		if returnsError(m) {
+			output = append(output,
+				fmt.Sprintf("if r%d == nil {", len(m.Results)-1),
+				observed,
+				"}",
+			)
+		} else {
+			output = append(output, observed)
+		}
+		output = append(output,
+			fmt.Sprintf("return %s", results),
+			"}",
+		)
+	}
+	return output
+}
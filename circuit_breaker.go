@@ -0,0 +1,115 @@
+package struct2interface
+
+import (
+	"fmt"
+	"strings"
+)
+
+// makeCircuitBreakerState appends the declarations shared by every circuit
+// breaker of a package.
+func makeCircuitBreakerState(output []string) []string {
+	return append(output,
+		"",
+		"// ErrCircuitOpen is returned by the generated circuit breakers while they are open.",
+		`var ErrCircuitOpen = errors.New("circuit breaker is open")`,
+		"",
+		"type circuitState int",
+		"",
+		"const (",
+		"circuitClosed circuitState = iota",
+		"circuitOpen",
+		"circuitHalfOpen",
+		")",
+	)
+}
+
+// makeCircuitBreaker appends a <Struct>CircuitBreaker wrapping the interface.
+// Methods returning an error count towards opening the breaker; other
+// methods are passed through.
+func makeCircuitBreaker(output []string, structName string, methods []Method, opts Options) []string {
+	var (
+		iface   = opts.interfaceName(structName)
+		breaker = structName + "CircuitBreaker"
+	)
+
+	output = append(output,
+		"",
+		fmt.Sprintf("// %s wraps a %s. After maxFailures consecutive errors it", breaker, iface),
+		"// opens and fails fast with ErrCircuitOpen; once resetTimeout has passed a",
+		"// single trial call decides whether it closes again.",
+		fmt.Sprintf("type %s struct {", breaker),
+		fmt.Sprintf("impl %s", iface),
+		"maxFailures int",
+		"resetTimeout time.Duration",
+		"",
+		"mu sync.Mutex",
+		"state circuitState",
+		"failures int",
+		"openedAt time.Time",
+		"}",
+		"",
+		fmt.Sprintf("var _ %s = (*%s)(nil)", iface, breaker),
+		"",
+		fmt.Sprintf("// New%s returns a closed circuit breaker around impl.", breaker),
+		fmt.Sprintf("func New%s(impl %s, maxFailures int, resetTimeout time.Duration) *%s {", breaker, iface, breaker),
+		fmt.Sprintf("return &%s{impl: impl, maxFailures: maxFailures, resetTimeout: resetTimeout}", breaker),
+		"}",
+		"",
+		fmt.Sprintf("func (w *%s) allow() bool {", breaker),
+		"w.mu.Lock()",
+		"defer w.mu.Unlock()",
+		"switch w.state {",
+		"case circuitOpen:",
+		"if time.Since(w.openedAt) < w.resetTimeout {",
+		"return false",
+		"}",
+		"w.state = circuitHalfOpen",
+		"return true",
+		"case circuitHalfOpen:",
+		"return false",
+		"}",
+		"return true",
+		"}",
+		"",
+		fmt.Sprintf("func (w *%s) record(err error) {", breaker),
+		"w.mu.Lock()",
+		"defer w.mu.Unlock()",
+		"if err == nil {",
+		"w.state, w.failures = circuitClosed, 0",
+		"return",
+		"}",
+		"w.failures++",
+		"if w.state == circuitHalfOpen || w.failures >= w.maxFailures {",
+		"w.state, w.openedAt = circuitOpen, time.Now()",
+		"}",
+		"}",
+	)
+
+	for _, m := range methods {
+		head, args := wrapperMethod(breaker, m)
+		call := fmt.Sprintf("w.impl.%s(%s)", m.Name, args)
+		output = append(output, "", head)
+		if !returnsError(m) {
+			output = append(output, passThrough(m, call), "}")
+			continue
+		}
+
+		var (
+			results = resultVars(m.Results)
+			last    = len(results) - 1
+		)
+		output = append(output, "if !w.allow() {")
+		for i, r := range m.Results[:last] {
+			output = append(output, fmt.Sprintf("var %s %s", results[i], r.Type))
+		}
+		output = append(output,
+			fmt.Sprintf("return %s", strings.Join(append(results[:last:last], "ErrCircuitOpen"), ", ")),
+			"}",
+			fmt.Sprintf("%s := %s", strings.Join(results, ", "), call),
+			fmt.Sprintf("w.record(%s)", results[last]),
+			fmt.Sprintf("return %s", strings.Join(results, ", ")),
+			"}",
+		)
+	}
+	return output
+}
@@ -1,23 +1,67 @@
 package main
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+
 	"github.com/hnlq715/struct2interface"
 	"github.com/spf13/cobra"
 )
 
 func main() {
 	var (
-		dir string
+		dir     string
+		file    string
+		structs []string
+		check   bool
+		workers int
 	)
 
 	root := &cobra.Command{
 		Use: "struct2interface",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return struct2interface.MakeDir(dir)
+			opts := struct2interface.Options{
+				Structs: structs,
+				Workers: workers,
+				Logger:  struct2interface.StderrLogger(),
+			}
+
+			if check {
+				outdated, err := struct2interface.ListOutdatedFiles(dir, opts)
+				if err != nil {
+					return err
+				}
+				for _, f := range outdated {
+					fmt.Fprintln(os.Stderr, f)
+				}
+				if len(outdated) > 0 {
+					os.Exit(1)
+				}
+				return nil
+			}
+
+			if file == "-" {
+				output, err := struct2interface.MakeReader(os.Stdin, filepath.Join(dir, "stdin.go"), opts)
+				if err != nil {
+					return err
+				}
+				_, err = os.Stdout.Write(output)
+				return err
+			}
+			if file != "" {
+				return struct2interface.MakeFileWithOptions(file, opts)
+			}
+			_, err := struct2interface.MakeDirWithOptions(dir, opts)
+			return err
 		},
 	}
 
 	root.Flags().StringVarP(&dir, "dir", "d", ".", "Go source file dir to read")
+	root.Flags().StringVarP(&file, "file", "f", "", "Single Go source file to read instead of --dir, e.g. $GOFILE, or - to read stdin and write the interface to stdout")
+	root.Flags().StringSliceVarP(&structs, "struct", "s", nil, "Only generate interfaces for the named structs (repeatable)")
+	root.Flags().IntVarP(&workers, "workers", "w", 1, "Number of files parsed in parallel, 0 for one per CPU; memory use grows with each worker")
+	root.Flags().BoolVar(&check, "check", false, "Exit 1 and list the interface files that are missing or stale instead of writing them")
 	if err := root.Execute(); err != nil {
 		panic(err)
 	}
@@ -0,0 +1,73 @@
+package struct2interface
+
+import (
+	"fmt"
+	"strings"
+)
+
+// makeDispatcher appends a <Interface>Dispatcher function that calls the
+// named method of an implementation, type-asserting each argument and
+// collecting the results.
+func makeDispatcher(output []string, structName string, methods []Method, opts Options) []string {
+	var (
+		iface      = opts.interfaceName(structName)
+		dispatcher = iface + "Dispatcher"
+	)
+
+	output = append(output,
+		"",
+		fmt.Sprintf("// %s calls method on impl with args and returns its results.", dispatcher),
+		fmt.Sprintf("func %s(impl %s, method string, args []interface{}) ([]interface{}, error) {", dispatcher, iface),
+		"switch method {",
+	)
+
+	for _, m := range methods {
+		output = append(output,
+			fmt.Sprintf("case %q:", m.Name),
+			fmt.Sprintf("if len(args) != %d {", len(m.Params)),
+			fmt.Sprintf(`return nil, fmt.Errorf("%%s: expected %d arguments, got %%d", method, len(args))`, len(m.Params)),
+			"}",
+		)
+
+		var callArgs []string
+		for i, p := range m.Params {
+			var (
+				arg = fmt.Sprintf("a%d", i)
+				typ = p.Type
+			)
+			if strings.HasPrefix(typ, "...") {
+				typ = "[]" + strings.TrimPrefix(typ, "...")
+				callArgs = append(callArgs, arg+"...")
+			} else {
+				callArgs = append(callArgs, arg)
+			}
+			output = append(output,
+				fmt.Sprintf("%s, ok := args[%d].(%s)", arg, i, typ),
+				fmt.Sprintf("if !ok && args[%d] != nil {", i),
+				fmt.Sprintf(`return nil, fmt.Errorf("%%s: argument %d is %%T, not %s", method, args[%d])`, i, typ, i),
+				"}",
+			)
+		}
+
+		call := fmt.Sprintf("impl.%s(%s)", m.Name, strings.Join(callArgs, ", "))
+		if len(m.Results) == 0 {
+			output = append(output, call, "return nil, nil")
+			continue
+		}
+		results := make([]string, len(m.Results))
+		for i := range m.Results {
+			results[i] = fmt.Sprintf("r%d", i)
+		}
+		output = append(output,
+			fmt.Sprintf("%s := %s", strings.Join(results, ", "), call),
+			fmt.Sprintf("return []interface{}{%s}, nil", strings.Join(results, ", ")),
+		)
+	}
+
+	output = append(output,
+		"}",
+		`return nil, fmt.Errorf("unknown method %q", method)`,
+		"}",
+	)
+	return output
+}
@@ -0,0 +1,63 @@
+package struct2interface
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+)
+
+// canFastFormat reports whether go/format alone produces a valid file from
+// code: no import block is empty, every import is named uniquely and used,
+// and every qualifier refers to one of the imports, so goimports would
+// neither add nor remove any.
+func canFastFormat(code []byte) bool {
+	f, err := parser.ParseFile(token.NewFileSet(), "", code, 0)
+	if err != nil {
+		return false
+	}
+
+	for _, d := range f.Decls {
+		// only goimports drops the empty import block of the head
+		if gd, ok := d.(*ast.GenDecl); ok && gd.Tok == token.IMPORT && len(gd.Specs) == 0 {
+			return false
+		}
+	}
+
+	imported := make(map[string]bool)
+	for _, i := range f.Imports {
+		var name string
+		if i.Name != nil {
+			name = i.Name.Name
+		} else {
+			path, err := strconv.Unquote(i.Path.Value)
+			if err != nil {
+				return false
+			}
+			name = assumedPackageName(path)
+		}
+		if _, ok := imported[name]; ok || name == "_" || name == "." {
+			return false
+		}
+		imported[name] = false
+	}
+
+	fast := true
+	ast.Inspect(f, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return fast
+		}
+		if x, ok := sel.X.(*ast.Ident); ok {
+			if _, ok := imported[x.Name]; !ok {
+				fast = false
+			}
+			imported[x.Name] = true
+		}
+		return fast
+	})
+	for _, used := range imported {
+		fast = fast && used
+	}
+	return fast
+}
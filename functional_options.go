@@ -0,0 +1,54 @@
+package struct2interface
+
+import (
+	"fmt"
+	"strings"
+)
+
+// makeFunctionalOptions appends a <Struct>Option type and one constructor per
+// With* method, so that
+//
+//	func (c *Config) WithTimeout(d time.Duration) *Config
+//
+// becomes usable as NewConfigWithOptions(ConfigWithTimeout(time.Second)).
+func makeFunctionalOptions(output []string, structName string, methods []Method) []string {
+	var withMethods []Method
+	for _, m := range methods {
+		if strings.HasPrefix(m.Name, "With") && len(m.Params) > 0 {
+			withMethods = append(withMethods, m)
+		}
+	}
+	if len(withMethods) == 0 {
+		return output
+	}
+
+	option := structName + "Option"
+	output = append(output,
+		"",
+		fmt.Sprintf("// %s configures a %s.", option, structName),
+		fmt.Sprintf("type %s func(*%s)", option, structName),
+		"",
+		fmt.Sprintf("// New%sWithOptions returns a new %s with opts applied in order.", structName, structName),
+		fmt.Sprintf("func New%sWithOptions(opts ...%s) *%s {", structName, option, structName),
+		fmt.Sprintf("v := &%s{}", structName),
+		"for _, opt := range opts {",
+		"opt(v)",
+		"}",
+		"return v",
+		"}",
+	)
+
+	for _, m := range withMethods {
+		params, args := paramList(m.Params, "v")
+		output = append(output,
+			"",
+			fmt.Sprintf("// %s%s returns a %s calling %s.", structName, m.Name, option, m.Name),
+			fmt.Sprintf("func %s%s(%s) %s {", structName, m.Name, strings.Join(params, ", "), option),
+			fmt.Sprintf("return func(v *%s) {", structName),
+			fmt.Sprintf("v.%s(%s)", m.Name, strings.Join(args, ", ")),
+			"}",
+			"}",
+		)
+	}
+	return output
+}
@@ -0,0 +1,85 @@
+package struct2interface
+
+import (
+	"go/ast"
+	"go/token"
+)
+
+// rename replaces ident with name when slicing a source.
+type rename struct {
+	ident *ast.Ident
+	name  string
+}
+
+// typeParamNames returns the type parameter names of every generic type
+// declared in a, in declaration order.
+func typeParamNames(a *ast.File) map[string][]string {
+	names := make(map[string][]string)
+	for _, d := range a.Decls {
+		gd, ok := d.(*ast.GenDecl)
+		if !ok || gd.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gd.Specs {
+			ts := spec.(*ast.TypeSpec)
+			if ts.TypeParams == nil {
+				continue
+			}
+			for _, f := range ts.TypeParams.List {
+				for _, n := range f.Names {
+					names[ts.Name.Name] = append(names[ts.Name.Name], n.Name)
+				}
+			}
+		}
+	}
+	return names
+}
+
+// renaming returns s renaming, in the signature of fd, the receiver type
+// parameters that differ from the declared ones, e.g. U in
+// func (r *Repo[U]) Get() U for type Repo[T any]. The generated interface
+// declares the type parameters of the struct, so the methods must use them.
+func (s source) renaming(fd *ast.FuncDecl, declared []string) source {
+	recv, err := getReceiverType(fd)
+	if err != nil {
+		return s
+	}
+	if star, ok := recv.(*ast.StarExpr); ok {
+		recv = unparen(star.X)
+	}
+	var args []ast.Expr
+	switch index := recv.(type) {
+	case *ast.IndexExpr:
+		args = []ast.Expr{index.Index}
+	case *ast.IndexListExpr:
+		args = index.Indices
+	}
+
+	names := make(map[string]string)
+	for i, arg := range args {
+		ident, ok := arg.(*ast.Ident)
+		if !ok || i >= len(declared) || ident.Name == "_" || ident.Name == declared[i] {
+			continue
+		}
+		names[ident.Name] = declared[i]
+	}
+	if len(names) == 0 {
+		return s
+	}
+
+	var renames []rename
+	ast.Inspect(fd.Type, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.SelectorExpr:
+			// pkg.U names a type of package pkg
+			return false
+		case *ast.Ident:
+			if name := names[n.Name]; name != "" {
+				renames = append(renames, rename{ident: n, name: name})
+			}
+		}
+		return true
+	})
+	s.renames = renames
+	return s
+}
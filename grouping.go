@@ -0,0 +1,80 @@
+package struct2interface
+
+import (
+	"sort"
+	"unicode"
+)
+
+// groupMethodLines returns the interface body lines of methods ordered
+// according to grouping, see Options.MethodGrouping.
+func groupMethodLines(methods []Method, grouping string) []string {
+	switch grouping {
+	case "alphabetical":
+		sorted := append([]Method(nil), methods...)
+		sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+		return methodLines(sorted)
+	case "prefix":
+		return prefixGroupLines(methods)
+	}
+	return methodLines(methods)
+}
+
+// prefixGroupLines groups methods sharing a leading word under a comment, in
+// order of first appearance. Methods without a partner follow at the end.
+func prefixGroupLines(methods []Method) []string {
+	var (
+		prefixes []string
+		groups   = make(map[string][]Method)
+	)
+	for _, m := range methods {
+		prefix := methodPrefix(m.Name)
+		if _, ok := groups[prefix]; !ok {
+			prefixes = append(prefixes, prefix)
+		}
+		groups[prefix] = append(groups[prefix], m)
+	}
+
+	var (
+		lines  []string
+		others []Method
+	)
+	for _, prefix := range prefixes {
+		group := groups[prefix]
+		if len(group) < 2 {
+			others = append(others, group...)
+			continue
+		}
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, "// "+groupComment(prefix))
+		lines = append(lines, methodLines(group)...)
+	}
+	if len(others) > 0 && len(lines) > 0 {
+		lines = append(lines, "", "// Other methods")
+	}
+	return append(lines, methodLines(others)...)
+}
+
+// methodPrefix returns the leading word of a method name, e.g. Get for
+// GetUser and HTTP for HTTPGet.
+func methodPrefix(name string) string {
+	r := []rune(name)
+	for i := 1; i < len(r); i++ {
+		if unicode.IsUpper(r[i]) && (unicode.IsLower(r[i-1]) || i+1 < len(r) && unicode.IsLower(r[i+1])) {
+			return string(r[:i])
+		}
+	}
+	return name
+}
+
+// groupComment returns the comment introducing the methods with prefix.
+func groupComment(prefix string) string {
+	switch prefix {
+	case "Get":
+		return "Getters"
+	case "Set":
+		return "Setters"
+	}
+	return prefix + " methods"
+}
@@ -0,0 +1,46 @@
+package struct2interface
+
+import (
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// Logger receives the diagnostic output of the generation, one line per
+// call. *log.Logger implements it.
+type Logger interface {
+	Printf(format string, args ...interface{})
+}
+
+// StderrLogger returns a Logger printing to standard error.
+func StderrLogger() Logger {
+	return log.New(os.Stderr, "[struct2interface] ", log.LstdFlags)
+}
+
+// printfWriter passes every record written by a slog.TextHandler to a Logger.
+type printfWriter struct {
+	logger Logger
+}
+
+func (w printfWriter) Write(p []byte) (int, error) {
+	w.logger.Printf("%s", strings.TrimSuffix(string(p), "\n"))
+	return len(p), nil
+}
+
+// loggerHandler returns a handler printing records, without their time, to
+// logger.
+func loggerHandler(logger Logger) slog.Handler {
+	return slog.NewTextHandler(printfWriter{logger: logger}, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey && len(groups) == 0 {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+}
+
+// discardHandler drops every record.
+var discardHandler = slog.NewTextHandler(io.Discard, &slog.HandlerOptions{Level: slog.Level(1 << 10)})
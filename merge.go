@@ -0,0 +1,144 @@
+package struct2interface
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+)
+
+// mergedInterface is an interface collected by MergeInterfaces.
+type mergedInterface struct {
+	name       string
+	typeParams string
+	docs       []string
+	methods    []string
+	// signatures maps the method names, or embedded types, to their
+	// signature, for deduplication.
+	signatures map[string]string
+}
+
+// MergeInterfaces merges the interface files, e.g. several generated
+// interface_<Struct>.go, into a single file declaring the union of the
+// method sets of every interface. Methods declared with the same signature
+// in several files are kept once; a method declared with different
+// signatures is an error. The files must belong to the same package and
+// declarations other than interfaces are dropped.
+func MergeInterfaces(files []string, opts Options) ([]byte, error) {
+	var (
+		pkgName, constraint string
+		imports             []string
+		interfaces          []*mergedInterface
+		byName              = make(map[string]*mergedInterface)
+		fset                = token.NewFileSet()
+	)
+	for i, file := range files {
+		text, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		a, err := parser.ParseFile(fset, file, text, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		fileConstraint, err := parseBuildConstraint(a)
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			pkgName, constraint = a.Name.Name, fileConstraint
+		} else if a.Name.Name != pkgName {
+			return nil, fmt.Errorf("struct2interface: merging %s: package %s, want %s", file, a.Name.Name, pkgName)
+		} else if fileConstraint != constraint {
+			return nil, fmt.Errorf("struct2interface: merging %s: build constraint %q, want %q", file, fileConstraint, constraint)
+		}
+
+		for _, imp := range a.Imports {
+			if imp.Name != nil {
+				imports = append(imports, fmt.Sprintf("%s %s", imp.Name.Name, imp.Path.Value))
+			} else {
+				imports = append(imports, imp.Path.Value)
+			}
+		}
+
+		src := newSource(fset, a, text)
+		for _, d := range a.Decls {
+			gd, ok := d.(*ast.GenDecl)
+			if !ok || gd.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gd.Specs {
+				ts := spec.(*ast.TypeSpec)
+				it, ok := ts.Type.(*ast.InterfaceType)
+				if !ok {
+					continue
+				}
+				iface, ok := byName[ts.Name.Name]
+				if !ok {
+					iface = &mergedInterface{name: ts.Name.Name, signatures: make(map[string]string)}
+					if ts.TypeParams != nil {
+						iface.typeParams = src.slice(ts.TypeParams.Opening, ts.TypeParams.Closing+1)
+					}
+					doc := ts.Doc
+					if doc == nil && len(gd.Specs) == 1 {
+						doc = gd.Doc
+					}
+					iface.docs = commentLines(src, doc)
+					byName[ts.Name.Name] = iface
+					interfaces = append(interfaces, iface)
+				}
+				if err := iface.merge(src, it); err != nil {
+					return nil, fmt.Errorf("struct2interface: merging %s: %w", file, err)
+				}
+			}
+		}
+	}
+
+	imports = dedupImports(imports)
+
+	output := makeInterfaceHead(pkgName, opts.PackageComment, constraint, "", imports)
+	for _, iface := range interfaces {
+		output = append(output, iface.docs...)
+		output = append(output, fmt.Sprintf("type %s%s interface {", iface.name, iface.typeParams))
+		output = append(output, iface.methods...)
+		output = append(output, "}", "")
+	}
+	return renderCode(output, opts)
+}
+
+// merge adds the methods and embedded interfaces of it not yet in iface.
+func (iface *mergedInterface) merge(src source, it *ast.InterfaceType) error {
+	for _, f := range it.Methods.List {
+		var (
+			signature = src.slice(f.Type.Pos(), f.Type.End())
+			key       = signature
+		)
+		if len(f.Names) > 0 {
+			key = f.Names[0].Name
+			signature = src.slice(f.Names[0].Pos(), f.Type.End())
+		}
+		if existing, ok := iface.signatures[key]; ok {
+			if existing != signature {
+				return fmt.Errorf("method %s.%s declared as %s and %s", iface.name, key, existing, signature)
+			}
+			continue
+		}
+		iface.signatures[key] = signature
+		iface.methods = append(iface.methods, commentLines(src, f.Doc)...)
+		iface.methods = append(iface.methods, signature)
+	}
+	return nil
+}
+
+// commentLines returns the lines of cg as written in src.
+func commentLines(src source, cg *ast.CommentGroup) []string {
+	if cg == nil {
+		return nil
+	}
+	var lines []string
+	for _, c := range cg.List {
+		lines = append(lines, src.slice(c.Pos(), c.End()))
+	}
+	return lines
+}
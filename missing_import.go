@@ -0,0 +1,118 @@
+package struct2interface
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MissingImport is a qualified type used in a method signature whose package
+// is not imported by the generated file.
+type MissingImport struct {
+	Struct string
+	Method string
+	Type   string
+}
+
+// MissingImportError is returned when generated method signatures refer to
+// packages missing from the import list of the generated file.
+type MissingImportError struct {
+	Missing []MissingImport
+}
+
+func (e *MissingImportError) Error() string {
+	msgs := make([]string, len(e.Missing))
+	for i, m := range e.Missing {
+		msgs[i] = fmt.Sprintf("%s in %s.%s", m.Type, m.Struct, m.Method)
+	}
+	return "struct2interface: missing imports for " + strings.Join(msgs, ", ")
+}
+
+// importNames returns the names the given import specs are referred to by.
+func importNames(imports []string) map[string]struct{} {
+	names := make(map[string]struct{})
+	for _, i := range imports {
+		if fields := strings.Fields(i); len(fields) == 2 {
+			names[fields[0]] = struct{}{}
+			continue
+		}
+		if path, err := strconv.Unquote(i); err == nil {
+			names[assumedPackageName(path)] = struct{}{}
+		}
+	}
+	return names
+}
+
+// assumedPackageName guesses the name of the package at path the way
+// goimports does: the last element, skipping a major version suffix and
+// dropping a go- prefix and anything after a dot or dash.
+func assumedPackageName(path string) string {
+	elems := strings.Split(path, "/")
+	name := elems[len(elems)-1]
+	if len(elems) > 1 && isMajorVersion(name) {
+		name = elems[len(elems)-2]
+	}
+	name = strings.TrimPrefix(name, "go-")
+	if i := strings.IndexAny(name, ".-"); i >= 0 {
+		name = name[:i]
+	}
+	return name
+}
+
+// isMajorVersion reports whether elem is a module major version like v2.
+func isMajorVersion(elem string) bool {
+	if len(elem) < 2 || elem[0] != 'v' {
+		return false
+	}
+	_, err := strconv.Atoi(elem[1:])
+	return err == nil
+}
+
+// qualifiedTypes returns the pkg.Type identifiers used in the signature of m.
+func qualifiedTypes(m Method) []string {
+	expr, err := parser.ParseExpr("func" + strings.TrimPrefix(m.Code, m.Name))
+	if err != nil {
+		return nil
+	}
+	var types []string
+	ast.Inspect(expr, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if x, ok := sel.X.(*ast.Ident); ok {
+				types = append(types, x.Name+"."+sel.Sel.Name)
+			}
+		}
+		return true
+	})
+	return types
+}
+
+// checkImports verifies that every qualified type in the methods of structs
+// belongs to one of imports.
+func checkImports(imports []string, structs map[string][]Method) error {
+	var (
+		names   = importNames(imports)
+		missing []MissingImport
+	)
+	for structName, methods := range structs {
+		for _, m := range methods {
+			for _, t := range qualifiedTypes(m) {
+				if _, ok := names[t[:strings.Index(t, ".")]]; !ok {
+					missing = append(missing, MissingImport{Struct: structName, Method: m.Name, Type: t})
+				}
+			}
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	sort.SliceStable(missing, func(i, j int) bool {
+		if missing[i].Struct != missing[j].Struct {
+			return missing[i].Struct < missing[j].Struct
+		}
+		return missing[i].Method < missing[j].Method
+	})
+	return &MissingImportError{Missing: missing}
+}
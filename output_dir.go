@@ -0,0 +1,165 @@
+package struct2interface
+
+import (
+	"fmt"
+	"go/scanner"
+	"go/token"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// outputPackage returns the package of the files written to OutputDir.
+func (o Options) outputPackage() string {
+	return filepath.Base(filepath.Clean(o.OutputDir))
+}
+
+// checkOutputDir rejects an OutputDir that is not a valid package name and
+// the options generating code that only compiles in the source package.
+func checkOutputDir(opts Options) error {
+	if opts.OutputDir == "" {
+		return nil
+	}
+	if pkg := opts.outputPackage(); !token.IsIdentifier(pkg) {
+		return fmt.Errorf("struct2interface: OutputDir %s is not a valid package name", opts.OutputDir)
+	}
+	for _, o := range []struct {
+		name string
+		set  bool
+	}{
+		{"GenBuilder", opts.GenBuilder},
+		{"GenFunctionalOptions", opts.GenFunctionalOptions},
+		{"GenComplianceTest", opts.GenComplianceTest},
+		{"GenTestMock", opts.GenTestMock},
+		{"GenContractsPackage", opts.GenContractsPackage},
+	} {
+		if o.set {
+			return fmt.Errorf("struct2interface: OutputDir cannot be combined with %s", o.name)
+		}
+	}
+	return nil
+}
+
+// sourceTypes returns the types declared in the package in dir, with their
+// doc when in typeDoc. typeDoc alone misses the types of the files without
+// methods, and is all there is when dir cannot be read.
+func sourceTypes(opts Options, dir string, typeDoc map[string]string) map[string]string {
+	declared := make(map[string]string, len(typeDoc))
+	for typeName, doc := range typeDoc {
+		declared[typeName] = doc
+	}
+	types, err := packageTypes(dir)
+	if err != nil {
+		opts.logger().Debug("only qualifying the types of the files with methods", "dir", dir, "error", err)
+		return declared
+	}
+	for typeName := range types {
+		if _, ok := declared[typeName]; !ok {
+			declared[typeName] = ""
+		}
+	}
+	return declared
+}
+
+// qualifyView returns a copy of the methods of structs qualified as by
+// qualifyMethods, for a view written outside the package pkgName in dir,
+// and the import of that package when any type was qualified.
+func qualifyView(structs []string, methods map[string][]Method, dir, pkgName string, declared map[string]string) (map[string][]Method, string, error) {
+	var (
+		result    = make(map[string][]Method, len(structs))
+		qualified bool
+	)
+	for _, structName := range structs {
+		copied := make([]Method, len(methods[structName]))
+		for i, m := range methods[structName] {
+			m.Params = append([]Param(nil), m.Params...)
+			m.Results = append([]Param(nil), m.Results...)
+			copied[i] = m
+		}
+		q, err := qualifyMethods(copied, pkgName, declared)
+		if err != nil {
+			return nil, "", err
+		}
+		result[structName], qualified = copied, qualified || q
+	}
+	if !qualified {
+		return result, "", nil
+	}
+	imp, err := sourceImport(dir, pkgName)
+	return result, imp, err
+}
+
+// qualifyMethods prefixes the types of pkgName that methods refer to, which
+// are those in declared, with the package name, so that they can be used
+// from OutputDir. It reports whether any type was qualified.
+func qualifyMethods(methods []Method, pkgName string, declared map[string]string) (bool, error) {
+	var qualified bool
+	for i, m := range methods {
+		// the first identifier of the code is the method name
+		code, ok, err := qualifyTypes(m.Code, pkgName, declared, 1)
+		if err != nil {
+			return false, fmt.Errorf("struct2interface: method %s: %w", m.Name, err)
+		}
+		qualified = qualified || ok
+		methods[i].Code = code
+		for _, params := range [][]Param{m.Params, m.Results} {
+			for j, p := range params {
+				if params[j].Type, _, err = qualifyTypes(p.Type, pkgName, declared, 0); err != nil {
+					return false, fmt.Errorf("struct2interface: method %s: %w", m.Name, err)
+				}
+			}
+		}
+	}
+	return qualified, nil
+}
+
+// qualifyTypes prefixes the identifiers of code found in declared and not
+// already selected from a package with pkgName, apart from the first skip
+// ones. Unexported types cannot be qualified and are an error.
+func qualifyTypes(code, pkgName string, declared map[string]string, skip int) (string, bool, error) {
+	var (
+		b         strings.Builder
+		s         scanner.Scanner
+		fset      = token.NewFileSet()
+		file      = fset.AddFile("", fset.Base(), len(code))
+		last      int
+		prev      token.Token
+		qualified bool
+	)
+	s.Init(file, []byte(code), nil, 0)
+	for n := 0; ; n++ {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		if _, ok := declared[lit]; tok == token.IDENT && n >= skip && prev != token.PERIOD && ok {
+			if !token.IsExported(lit) {
+				return "", false, fmt.Errorf("unexported type %s cannot be used outside package %s", lit, pkgName)
+			}
+			offset := file.Offset(pos)
+			b.WriteString(code[last:offset])
+			b.WriteString(pkgName + ".")
+			last, qualified = offset, true
+		}
+		prev = tok
+	}
+	if !qualified {
+		return code, false, nil
+	}
+	b.WriteString(code[last:])
+	return b.String(), true, nil
+}
+
+// sourceImport returns the import of the package in dir, named when its
+// path does not end in pkgName.
+func sourceImport(dir, pkgName string) (string, error) {
+	pkgPath, err := importPath(dir)
+	if err != nil {
+		return "", err
+	}
+	if path.Base(pkgPath) != pkgName {
+		return pkgName + " " + strconv.Quote(pkgPath), nil
+	}
+	return strconv.Quote(pkgPath), nil
+}
@@ -0,0 +1,68 @@
+package struct2interface
+
+import (
+	"fmt"
+	"strings"
+)
+
+// makeRetry appends a <Struct>WithRetry wrapping the interface. Methods
+// returning an error are retried with exponential backoff; other methods are
+// passed through.
+func makeRetry(output []string, structName string, methods []Method, opts Options) []string {
+	var (
+		iface = opts.interfaceName(structName)
+		retry = structName + "WithRetry"
+	)
+
+	output = append(output,
+		"",
+		fmt.Sprintf("// %s wraps a %s. Methods returning an error are retried up", retry, iface),
+		"// to MaxRetries times, waiting BaseDelay before the first retry and",
+		"// Multiplier times longer before every following one.",
+		fmt.Sprintf("type %s struct {", retry),
+		fmt.Sprintf("impl %s", iface),
+		"",
+		"MaxRetries int",
+		"BaseDelay time.Duration",
+		"Multiplier float64",
+		"}",
+		"",
+		fmt.Sprintf("var _ %s = (*%s)(nil)", iface, retry),
+		"",
+		fmt.Sprintf("// New%s returns impl retried with the given backoff.", retry),
+		fmt.Sprintf("func New%s(impl %s, maxRetries int, baseDelay time.Duration, multiplier float64) *%s {", retry, iface, retry),
+		fmt.Sprintf("return &%s{impl: impl, MaxRetries: maxRetries, BaseDelay: baseDelay, Multiplier: multiplier}", retry),
+		"}",
+		"",
+		fmt.Sprintf("func (w *%s) backoff(attempt int) time.Duration {", retry),
+		"delay := float64(w.BaseDelay)",
+		"for i := 0; i < attempt; i++ {",
+		"delay *= w.Multiplier",
+		"}",
+		"return time.Duration(delay)",
+		"}",
+	)
+
+	for _, m := range methods {
+		head, args := wrapperMethod(retry, m, "attempt")
+		call := fmt.Sprintf("w.impl.%s(%s)", m.Name, args)
+		output = append(output, "", head)
+		if !returnsError(m) {
+			output = append(output, passThrough(m, call), "}")
+			continue
+		}
+
+		results := resultVars(m.Results)
+		output = append(output,
+			"for attempt := 0; ; attempt++ {",
+			fmt.Sprintf("%s := %s", strings.Join(results, ", "), call),
+			fmt.Sprintf("if %s == nil || attempt >= w.MaxRetries {", results[len(results)-1]),
+			fmt.Sprintf("return %s", strings.Join(results, ", ")),
+			"}",
+			"time.Sleep(w.backoff(attempt))",
+			"}",
+			"}",
+		)
+	}
+	return output
+}
@@ -1,326 +1,2736 @@
 package struct2interface
 
 import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"go/ast"
+	"go/build"
+	"go/build/constraint"
 	"go/doc"
+	"go/format"
 	"go/parser"
 	"go/token"
+	"io"
 	"io/fs"
-	"io/ioutil"
-	"log"
+	"log/slog"
+	"os"
+	"os/exec"
 	"path/filepath"
+	"regexp"
+	"runtime"
+	"runtime/pprof"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
 
 	"golang.org/x/tools/imports"
 )
 
-type makeInterfaceFile struct {
+// Options configures how interfaces are generated.
+type Options struct {
+	// Structs restricts generation to the named structs. When empty,
+	// every struct with exported methods gets an interface.
+	Structs []string
+
+	// StructFilter, when non-nil, restricts generation to the structs it
+	// returns true for, in addition to Structs.
+	StructFilter func(structName string) bool
+
+	// InterfaceSuffix is appended to a struct name to name its interface.
+	// Defaults to "Interface".
+	InterfaceSuffix string
+
+	// InterfaceNamer, when non-nil, names the interface of structName in
+	// place of InterfaceSuffix. Structs it returns "" for are skipped.
+	InterfaceNamer func(structName string) string
+
+	// OutputFileName, when non-nil, returns the base name of the interface
+	// file of pkgName in place of interface_<pkg>.go. Files with that name
+	// are not parsed.
+	OutputFileName func(pkgName string) string
+
+	// SlogHandler, when non-nil, receives the log records of the generation.
+	SlogHandler slog.Handler
+
+	// Logger, when non-nil and SlogHandler is not set, receives the log
+	// records as text lines. Without either the generation is silent.
+	Logger Logger
+
+	// ProfileCPU, when non-nil, receives a CPU profile of MakeDirWithOptions.
+	ProfileCPU io.Writer
+
+	// StrictMode turns every warning, such as a method signature using an
+	// undeclared identifier, into an error aborting the generation.
+	StrictMode bool
+
+	// ContinueOnError skips, with a warning, the files that fail to be read
+	// or parsed instead of failing.
+	ContinueOnError bool
+
+	// StructDocSuffix is the text placed between the interface name and the
+	// struct's own doc in the interface doc comment. Defaults to " ...\n".
+	StructDocSuffix string
+
+	// MethodAnnotations holds comment lines, such as "// thread-safe: yes",
+	// prepended to the doc of interface methods. Keys are
+	// "StructName.MethodName", or a bare method name for every struct.
+	MethodAnnotations map[string][]string
+
+	// SkipWellKnownInterfaces lets MethodSet and SkipDeprecated drop the
+	// methods of fmt.Stringer, error, io.Reader, io.Writer and io.Closer,
+	// which are otherwise always kept.
+	SkipWellKnownInterfaces bool
+
+	// SkipEncodingMethods lets MethodSet and SkipDeprecated drop the
+	// MarshalJSON and UnmarshalJSON methods of json.Marshaler and
+	// json.Unmarshaler, which are otherwise always kept.
+	SkipEncodingMethods bool
+
+	// SkipGobMethods does the same for the GobEncode and GobDecode methods
+	// of gob.GobEncoder and gob.GobDecoder.
+	SkipGobMethods bool
+
+	// MaxMethodLength, when positive, replaces the interface methods whose
+	// signature is longer than that many characters with a comment noting
+	// their omission. They are left out of the generated helpers as well.
+	MaxMethodLength int
+
+	// StripReturnNames drops the names of named results from the interface
+	// methods, e.g. (n int, err error) becomes (int, error).
+	StripReturnNames bool
+
+	// Workers is the number of files parsed concurrently, by default
+	// runtime.NumCPU() when 0 or negative. Memory use grows with the number
+	// of files parsed at the same time. Hooks such as PreProcess may be
+	// called concurrently unless Workers is 1.
+	Workers int
+
+	// EmitAssertions appends to the interface file a compile-time assertion
+	// that every non-generic struct implements its interface, so the build
+	// breaks when they drift apart. It is ignored with OutputDir.
+	EmitAssertions bool
+
+	// GenComplianceTest additionally writes interface_compliance_test.go,
+	// asserting in one test per struct that it still implements its
+	// generated interface.
+	GenComplianceTest bool
+
+	// GenTestMock additionally writes interface_<pkg>_mock_test.go with a
+	// dependency-free <Struct>Mock per struct, whose methods call
+	// On<Method> function fields.
+	GenTestMock bool
+
+	// GenMockRegistry additionally writes mock_registry_test.go with a
+	// MockRegistry map from interface names to the reflect.Type of their
+	// mock, filled at package init. It requires GenTestMock.
+	GenMockRegistry bool
+
+	// GenContractsPackage writes the interface of every struct to
+	// <dir>/contracts/<Interface>.go in package contracts instead of next to
+	// the source, and asserts in <dir>/compliance.go that each struct
+	// implements it. The methods must not refer to types of the source
+	// package, which contracts cannot import.
+	GenContractsPackage bool
+
+	// PackageComment, when set, is written as the package doc comment of
+	// every interface file, for packages such as contracts that have no
+	// other files.
+	PackageComment string
+
+	// IncludePackageDoc copies the doc comment of the source package, from
+	// doc.go or else the first file having one, to the interface files of
+	// the package. PackageComment takes precedence.
+	IncludePackageDoc bool
+
+	// BuildTags are extra //go:build expressions, such as "!integration",
+	// that are ANDed with the constraints of the source files.
+	BuildTags []string
+
+	// ExcludeFiles skips the source files whose base name matches one of
+	// the patterns, e.g. `\.pb\.go$`, in addition to the interface_ and
+	// mock_ files.
+	ExcludeFiles []*regexp.Regexp
+
+	// ExcludeDirs skips the subdirectories, with everything below them,
+	// whose base name matches one of the patterns, e.g. `^vendor$`.
+	ExcludeDirs []*regexp.Regexp
+
+	// SkipByFilePattern skips the files and subdirectories whose path, as
+	// walked, matches one of the filepath.Match patterns. The trailing
+	// elements of the path are matched too, so "*/testdata/*" skips the
+	// content of testdata directories at any depth.
+	SkipByFilePattern []string
+
+	// IncludeTestFiles also generates interfaces for the structs of
+	// _test.go files, which are skipped by default. They are written to
+	// test files of their own, such as interface_<pkg>_test.go, and
+	// PathMapper receives the test file as sourcePath.
+	IncludeTestFiles bool
+
+	// BuildContext skips the source files it excludes by their //go:build
+	// constraints or _GOOS_GOARCH suffixes. The default, build.Default,
+	// keeps those of the current platform; set it to cross-generate, or
+	// with UseAllFiles to keep every file. The constraints of the files
+	// used are carried over to the interface file.
+	BuildContext *build.Context
+
+	// PathMapper, when non-nil, returns the path the interface file for
+	// dirPath is written to. sourcePath is the first source file that
+	// contributed to it. The default is dirPath/interface_<pkg>.go.
+	PathMapper func(sourcePath, dirPath string) string
+
+	// SourceHash records a sha256 hash of the source files in the header of
+	// every generated interface file. ListOutdatedFiles and CheckDir then
+	// skip, without generating them again, the packages whose interface
+	// files record the hash of their current sources, so changes of the
+	// options alone are not detected.
+	SourceHash bool
+
+	// DisableMkdirAll makes writing into a missing output directory an
+	// error. By default missing directories, including parents, are created.
+	DisableMkdirAll bool
+
+	// WritePermissions are the permissions of the written files, 0644 when
+	// zero.
+	WritePermissions os.FileMode
+
+	// OutputDir, when set, is the directory the interface files are written
+	// to instead of the source directory, in the package named after it.
+	// Types of the source package are qualified and imported, so they must
+	// be exported. Options generating code bound to the structs, such as
+	// GenBuilder or GenTestMock, cannot be combined with it, and packages
+	// of the same name are an error as they would share a file.
+	OutputDir string
+
+	// GenBuilder additionally generates a <Struct>Builder with a
+	// With<Field> setter per struct field and a Build method returning the
+	// struct as its interface.
+	GenBuilder bool
+
+	// GenDispatcher additionally generates a <Interface>Dispatcher function
+	// calling a method of the interface by name with []interface{}
+	// arguments, for scripting engines and RPC frameworks.
+	GenDispatcher bool
+
+	// SkipDeprecated leaves out methods documented with a "Deprecated:"
+	// paragraph.
+	SkipDeprecated bool
+
+	// ForbidDotImports makes source files with dot imports an error instead
+	// of a warning.
+	ForbidDotImports bool
+
+	// ErrorOnMissingImport checks with `go list` that every import of a
+	// generated file resolves from the source directory, and fails if one
+	// does not.
+	ErrorOnMissingImport bool
+
+	// MethodGrouping controls the order of methods in an interface:
+	// "none" (or empty) keeps declaration order, taking the files of a
+	// package in path order, "alphabetical" sorts them by name and "prefix"
+	// groups methods sharing a leading word, such as Get or Set, under a
+	// comment.
+	MethodGrouping string
+
+	// BaseInterface, when set, is embedded in every generated interface,
+	// e.g. "io.Closer" or "github.com/acme/repo.Base[int]". The package
+	// before the last dot is imported.
+	BaseInterface string
+
+	// EmbedParentInterface embeds the interface of every struct embedded in
+	// a struct of the same package into the interface of the outer struct,
+	// when both are generated into the same file. It cannot be combined with
+	// the options generating implementations, such as GenTestMock.
+	EmbedParentInterface bool
+
+	// InterfaceTemplate, when set, is a text/template rendering the
+	// signature of every interface method in place of the built-in
+	// formatting. It is executed with the fields MethodName, Params and
+	// Results, the latter two being []Param.
+	InterfaceTemplate string
+
+	// GroupBy chooses how interfaces are spread over files: "package" (or
+	// empty) writes one file per package, "struct" one
+	// interface_<Struct>.go per struct and "custom" the file returned by
+	// GroupByFunc. Generated helpers follow their struct; the functions
+	// interface stays in the package file. There is no "directory" value,
+	// as a Go file cannot hold several packages.
+	GroupBy string
+
+	// GroupByFunc returns the output path for structName when GroupBy is
+	// "custom". Structs mapped to the same path share a file.
+	GroupByFunc func(structName, pkgName, dir string) string
+
+	// PerStructFile writes the interface of every struct to its own
+	// interface_<Struct>.go, like GroupBy "struct". Unless DisableFormatting
+	// is set, each file keeps only the imports its methods use.
+	PerStructFile bool
+
+	// MethodSet selects the methods of a struct by receiver: "all" (or
+	// empty) keeps every method, "pointer" only those declared on *T and
+	// "value" only those declared on T.
+	MethodSet string
+
+	// GenFunctionalOptions additionally generates a <Struct>Option func type
+	// with one constructor per With* method of the struct.
+	GenFunctionalOptions bool
+
+	// GenCircuitBreaker additionally generates a <Struct>CircuitBreaker
+	// implementing the interface. After a number of consecutive errors it
+	// fails fast with ErrCircuitOpen until a reset timeout has passed.
+	GenCircuitBreaker bool
+
+	// GenRetry additionally generates a <Struct>WithRetry implementing the
+	// interface, which retries methods returning an error with exponential
+	// backoff.
+	GenRetry bool
+
+	// GenOTelTracer additionally generates a <Struct>Traced implementing
+	// the interface, which wraps every call in an OpenTelemetry span. The
+	// source module must depend on go.opentelemetry.io/otel.
+	GenOTelTracer bool
+
+	// GenCache additionally generates a <Struct>Cache implementing the
+	// interface. Methods named with one of CachePrefixes are memoized by
+	// their arguments in a sync.Map; any other call clears the cache.
+	GenCache bool
+
+	// CachePrefixes overrides the method name prefixes GenCache memoizes,
+	// Get, List, Find, Fetch and Read by default.
+	CachePrefixes []string
+
+	// GenTimeoutWrapper additionally generates a <Struct>WithTimeout
+	// implementing the interface. Methods taking a context.Context first
+	// get a context bounded by Timeout, or their MethodTimeouts entry; the
+	// others, and those without a timeout, are passed through.
+	GenTimeoutWrapper bool
+
+	// Timeout is the default timeout of GenTimeoutWrapper.
+	Timeout time.Duration
+
+	// MethodTimeouts overrides Timeout per method. Keys are
+	// "StructName.MethodName", or a bare method name for every struct.
+	MethodTimeouts map[string]time.Duration
+
+	// GenChangeDetector additionally generates a <Struct>ChangeDetector
+	// implementing the interface, which calls an OnChange callback when a
+	// method returns values, its error aside, different from those of its
+	// previous successful call.
+	GenChangeDetector bool
+
+	// IncludeFunctions additionally collects the exported package-level
+	// functions into one interface, named by FunctionsInterfaceName or
+	// <Pkg>Functions. For Structs, the package name selects them.
+	IncludeFunctions bool
+
+	// FunctionsInterfaceName overrides the name of the IncludeFunctions
+	// interface.
+	FunctionsInterfaceName string
+
+	// DisableFormatting writes the generated code as is, without running
+	// it through goimports. Unused imports are then kept.
+	DisableFormatting bool
+
+	// NoFormatting is the former name of DisableFormatting.
+	//
+	// Deprecated: use DisableFormatting.
+	NoFormatting bool
+
+	// FastFormat formats with go/format instead of goimports when the
+	// copied imports already match the types used by the generated file.
+	FastFormat bool
+
+	// PreProcess, when non-nil, transforms the raw bytes of every source
+	// file before it is parsed.
+	PreProcess func(src []byte) ([]byte, error)
+
+	// OnDuplicateMethod, when non-nil, is called when a method of structName
+	// is declared in both file1 and file2. If it returns nil the declaration
+	// in file1 is kept, otherwise generation fails with its error. When nil,
+	// both declarations end up in the interface.
+	OnDuplicateMethod func(structName, methodName, file1, file2 string) error
+
+	// FileProcessors transform the source of every file, in order and after
+	// PreProcess, before it is parsed.
+	FileProcessors []FileProcessor
+
+	// PostProcess, when non-nil, transforms every formatted file just
+	// before it is written.
+	PostProcess func(generated []byte) ([]byte, error)
+
+	// BeforeWrite, when non-nil, is called with the output path and final
+	// content of every file just before it is written and may modify the
+	// content. If it fails, that file is skipped and the error is returned
+	// once every other file has been written.
+	BeforeWrite func(path string, content []byte) ([]byte, error)
+
+	// WriteHook, when non-nil, is called instead of writing every file to
+	// disk, e.g. to store it elsewhere. No directory is created then.
+	WriteHook func(path string, content []byte) error
+}
+
+// FileProcessor transforms the source of a file before it is parsed.
+type FileProcessor interface {
+	Process(path string, src []byte) ([]byte, error)
+}
+
+// FileProcessorFunc adapts a function to a FileProcessor.
+type FileProcessorFunc func(path string, src []byte) ([]byte, error)
+
+// Process calls f(path, src).
+func (f FileProcessorFunc) Process(path string, src []byte) ([]byte, error) {
+	return f(path, src)
+}
+
+// interfaceName returns the name of the interface generated for structName.
+func (o Options) interfaceName(structName string) string {
+	if o.InterfaceNamer != nil {
+		return o.InterfaceNamer(structName)
+	}
+	if o.InterfaceSuffix != "" {
+		return structName + o.InterfaceSuffix
+	}
+	return structName + "Interface"
+}
+
+// withDirectiveNames returns o naming the interfaces of the structs of files
+// with a //struct2interface:name=<name> or // struct2interface:name <name>
+// directive after it. The other structs keep the name given by o.
+func (o Options) withDirectiveNames(files []*ParsedFile) Options {
+	names := make(map[string]string)
+	for _, f := range files {
+		for structName, d := range f.Directives {
+			if name := d["name"]; name != "" && name != "-" {
+				names[structName] = name
+			}
+		}
+	}
+	if len(names) == 0 {
+		return o
+	}
+	namer := o.interfaceName
+	o.InterfaceNamer = func(structName string) string {
+		if name, ok := names[structName]; ok {
+			return name
+		}
+		return namer(structName)
+	}
+	return o
+}
+
+// logger returns the logger of the generation.
+func (o Options) logger() *slog.Logger {
+	switch {
+	case o.SlogHandler != nil:
+		return slog.New(o.SlogHandler)
+	case o.Logger != nil:
+		return slog.New(loggerHandler(o.Logger))
+	}
+	return slog.New(discardHandler)
+}
+
+// warn logs msg with its key-value args, or returns it as an error in
+// StrictMode.
+func (o Options) warn(msg string, args ...interface{}) error {
+	if !o.StrictMode {
+		o.logger().Warn(msg, args...)
+		return nil
+	}
+	var b strings.Builder
+	b.WriteString("struct2interface: " + msg)
+	for i := 0; i+1 < len(args); i += 2 {
+		fmt.Fprintf(&b, " %v=%v", args[i], args[i+1])
+	}
+	return errors.New(b.String())
+}
+
+// outputFileName returns the base name of the interface file of pkgName.
+func (o Options) outputFileName(pkgName string) string {
+	if o.OutputFileName != nil {
+		return o.OutputFileName(pkgName)
+	}
+	return "interface_" + pkgName + ".go"
+}
+
+// structDocSuffix returns the text following the interface name in its doc.
+func (o Options) structDocSuffix() string {
+	if o.StructDocSuffix != "" {
+		return o.StructDocSuffix
+	}
+	return " ...\n"
+}
+
+// groupFileName returns the file the interface of structName is written to,
+// given the file of its package.
+func (o Options) groupFileName(structName, pkgName, dir, pkgFile string) string {
+	switch {
+	case o.GroupBy == "struct" || o.PerStructFile:
+		return filepath.Join(dir, "interface_"+structName+".go")
+	case o.GroupBy == "custom":
+		return o.GroupByFunc(structName, pkgName, dir)
+	}
+	return pkgFile
+}
+
+// buildContext returns the build context selecting the source files.
+func (o Options) buildContext() *build.Context {
+	if o.BuildContext == nil {
+		return &build.Default
+	}
+	return o.BuildContext
+}
+
+// writePermissions returns the permissions of the written files.
+func (o Options) writePermissions() os.FileMode {
+	if o.WritePermissions == 0 {
+		return 0644
+	}
+	return o.WritePermissions
+}
+
+// workers returns the number of files to parse concurrently.
+func (o Options) workers() int {
+	if o.Workers <= 0 {
+		return runtime.NumCPU()
+	}
+	return o.Workers
+}
+
+// functionsInterfaceName returns the name of the interface collecting the
+// package-level functions of pkgName.
+func (o Options) functionsInterfaceName(pkgName string) string {
+	if o.FunctionsInterfaceName != "" {
+		return o.FunctionsInterfaceName
+	}
+	return strings.ToUpper(pkgName[:1]) + pkgName[1:] + "Functions"
+}
+
+// cachePrefixes returns the prefixes of the methods GenCache memoizes.
+func (o Options) cachePrefixes() []string {
+	if o.CachePrefixes != nil {
+		return o.CachePrefixes
+	}
+	return []string{"Get", "List", "Find", "Fetch", "Read"}
+}
+
+// methodTimeout returns the GenTimeoutWrapper timeout of a method.
+func (o Options) methodTimeout(structName, methodName string) time.Duration {
+	if d, ok := o.MethodTimeouts[structName+"."+methodName]; ok {
+		return d
+	}
+	if d, ok := o.MethodTimeouts[methodName]; ok {
+		return d
+	}
+	return o.Timeout
+}
+
+// keepWellKnown reports whether m survives the method filters as part of a
+// well-known, JSON or gob interface.
+func (o Options) keepWellKnown(m Method) bool {
+	return (!o.SkipWellKnownInterfaces && hasSignature(wellKnownMethods, m)) ||
+		(!o.SkipEncodingMethods && hasSignature(jsonMethods, m)) ||
+		(!o.SkipGobMethods && hasSignature(gobMethods, m))
+}
+
+// includeStruct reports whether an interface should be generated for structName.
+func (o Options) includeStruct(structName string) bool {
+	if len(o.Structs) == 0 {
+		return true
+	}
+	for _, s := range o.Structs {
+		if s == structName {
+			return true
+		}
+	}
+	return false
+}
+
+// ParsedFile describes the structs and exported methods found in one Go
+// source file.
+type ParsedFile struct {
+	Path       string
 	DirPath    string
 	PkgName    string
+	Constraint string
 	Structs    []string
 	TypeDoc    map[string]string
-	AllMethods map[string][]string
-	AllImports []string
+	Methods    map[string][]Method
+	Imports    []string
+	// Directives holds the //struct2interface: directives of each struct.
+	Directives map[string]map[string]string
+	// Fields holds the fields of every struct declared in the file.
+	Fields map[string][]Field
+	// TypeParams holds the type parameter list, such as "[A, B any]", of
+	// every generic struct declared in the file.
+	TypeParams map[string]string
+	// Constructors holds the exported functions of the file returning a
+	// pointer to each struct, such as NewFoo for *Foo.
+	Constructors map[string][]string
+	// Functions holds the exported package-level functions of the file.
+	Functions []Method
+
+	srcHash []byte
+	pkgDoc  string // the package doc comment
+}
+
+// Field is a struct field. Embedded fields are named after their type.
+type Field struct {
+	Name     string
+	Type     string
+	Embedded bool
+}
+
+type Method struct {
+	Name    string
+	Code    string
+	Docs    []string
+	Params  []Param
+	Results []Param
+	// Directives holds the //struct2interface: directives of the method.
+	Directives map[string]string
+	// PointerReceiver reports whether the method is declared on *T.
+	PointerReceiver bool
+
+	params     string   // the parameter list of Code
+	unresolved []string // see unresolvedIdents
+}
+
+// Param is a method parameter or result. Name is empty for unnamed ones and
+// Type starts with "..." for a variadic parameter.
+type Param struct {
+	Name string
+	Type string
+}
+
+// Deprecated reports whether the documentation of m has a paragraph starting
+// with "Deprecated:".
+func (m *Method) Deprecated() bool {
+	for _, d := range m.Docs {
+		if strings.HasPrefix(strings.TrimSpace(strings.TrimPrefix(d, "//")), "Deprecated:") {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Method) Lines() []string {
+	var lines []string
+	lines = append(lines, m.Docs...)
+	lines = append(lines, m.Code)
+	return lines
+}
+
+// source is the text of a parsed file, sliced by the positions of its nodes,
+// which are offset by the base of the file in its FileSet. Identifiers listed
+// in renames are replaced while slicing.
+type source struct {
+	text    []byte
+	base    int
+	renames []rename
+}
+
+func newSource(fset *token.FileSet, a *ast.File, text []byte) source {
+	return source{text: text, base: fset.File(a.Pos()).Base()}
+}
+
+func (s source) slice(from, to token.Pos) string {
+	var (
+		b   strings.Builder
+		pos = from
+	)
+	for _, r := range s.renames {
+		if r.ident.Pos() < from || r.ident.End() > to {
+			continue
+		}
+		b.Write(s.text[int(pos)-s.base : int(r.ident.Pos())-s.base])
+		b.WriteString(r.name)
+		pos = r.ident.End()
+	}
+	b.Write(s.text[int(pos)-s.base : int(to)-s.base])
+	return b.String()
+}
+
+func getReceiverTypeName(src source, fl interface{}) (string, *ast.FuncDecl) {
+	fd, ok := fl.(*ast.FuncDecl)
+	if !ok {
+		return "", nil
+	}
+	t, err := getReceiverType(fd)
+	if err != nil {
+		return "", nil
+	}
+	if star, ok := t.(*ast.StarExpr); ok {
+		t = unparen(star.X)
+	}
+	// strip the type arguments of a generic receiver such as *Pair[A, B]
+	switch index := t.(type) {
+	case *ast.IndexExpr:
+		t = index.X
+	case *ast.IndexListExpr:
+		t = index.X
+	}
+	st := src.slice(t.Pos(), t.End())
+	return st, fd
+}
+
+func getReceiverType(fd *ast.FuncDecl) (ast.Expr, error) {
+	if fd.Recv == nil {
+		return nil, fmt.Errorf("fd is not a method, it is a function")
+	}
+	return unparen(fd.Recv.List[0].Type), nil
+}
+
+// unparen strips any parentheses around e, e.g. the receiver in func ((*T)) M().
+func unparen(e ast.Expr) ast.Expr {
+	for {
+		p, ok := e.(*ast.ParenExpr)
+		if !ok {
+			return e
+		}
+		e = p.X
+	}
+}
+
+func formatFieldList(src source, fl *ast.FieldList) []string {
+	if fl == nil {
+		return nil
+	}
+	var parts []string
+	for _, l := range fl.List {
+		names := make([]string, len(l.Names))
+		for i, n := range l.Names {
+			names[i] = n.Name
+		}
+		t := src.slice(l.Type.Pos(), l.Type.End())
+
+		if len(names) > 0 {
+			typeSharingArgs := strings.Join(names, ", ")
+			parts = append(parts, fmt.Sprintf("%s %s", typeSharingArgs, t))
+		} else {
+			parts = append(parts, t)
+		}
+	}
+	return parts
+}
+
+// parseParams returns one Param per name in fl.
+func parseParams(src source, fl *ast.FieldList) []Param {
+	if fl == nil {
+		return nil
+	}
+	var params []Param
+	for _, l := range fl.List {
+		t := src.slice(l.Type.Pos(), l.Type.End())
+		if len(l.Names) == 0 {
+			params = append(params, Param{Type: t})
+			continue
+		}
+		for _, n := range l.Names {
+			params = append(params, Param{Name: n.Name, Type: t})
+		}
+	}
+	return params
+}
+
+// makeMethod describes the method or function fd as an interface method.
+func makeMethod(src source, fd *ast.FuncDecl) Method {
+	params := formatFieldList(src, fd.Type.Params)
+	ret := formatResults(src, fd.Type.Results)
+	method := fmt.Sprintf("%s(%s)%s", fd.Name.String(), strings.Join(params, ", "), ret)
+	var docs []string
+	if fd.Doc != nil {
+		for _, d := range fd.Doc.List {
+			if isDirective(d.Text) {
+				continue
+			}
+			docs = append(docs, src.slice(d.Pos(), d.End()))
+		}
+		// drop the blank line that separated a stripped directive
+		for len(docs) > 0 && strings.TrimSpace(docs[len(docs)-1]) == "//" {
+			docs = docs[:len(docs)-1]
+		}
+	}
+	return Method{
+		Name:       fd.Name.Name,
+		Code:       method,
+		Docs:       docs,
+		Params:     parseParams(src, fd.Type.Params),
+		Results:    parseParams(src, fd.Type.Results),
+		Directives: parseDirectives(fd.Doc),
+		params:     strings.Join(params, ", "),
+	}
+}
+
+// formatResults renders a result list the way gofmt would: nothing for zero
+// results, a bare type for a single unnamed result and a parenthesized list
+// otherwise.
+func formatResults(src source, fl *ast.FieldList) string {
+	parts := formatFieldList(src, fl)
+	switch {
+	case len(parts) == 0:
+		return ""
+	case len(parts) == 1 && len(fl.List[0].Names) == 0:
+		return " " + parts[0]
+	}
+	return " (" + strings.Join(parts, ", ") + ")"
+}
+
+// BadDeclError reports a declaration of a source file that failed to parse,
+// whose methods would otherwise be missing from the interface.
+type BadDeclError struct {
+	Pos token.Position
+	Err error
+}
+
+func (e *BadDeclError) Error() string {
+	return fmt.Sprintf("bad declaration at %s: %v", e.Pos, e.Err)
+}
+
+func (e *BadDeclError) Unwrap() error {
+	return e.Err
 }
 
-type Method struct {
-	Code string
-	Docs []string
-}
+func parseStruct(src []byte) (*ParsedFile, error) {
+	return parseStructFileSet(token.NewFileSet(), "", src)
+}
+
+// parseStructFileSet is parseStruct adding src to fset under filename, so the
+// positions of all files parsed by one MakeDir call share a single FileSet.
+func parseStructFileSet(fset *token.FileSet, filename string, text []byte) (*ParsedFile, error) {
+	a, err := parser.ParseFile(fset, filename, text, parser.ParseComments)
+	if a != nil {
+		for _, d := range a.Decls {
+			if bad, ok := d.(*ast.BadDecl); ok {
+				return nil, &BadDeclError{Pos: fset.Position(bad.Pos()), Err: err}
+			}
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	src := newSource(fset, a, text)
+	buildConstraint, err := parseBuildConstraint(a)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed := &ParsedFile{
+		PkgName:      a.Name.Name,
+		Constraint:   buildConstraint,
+		Methods:      make(map[string][]Method),
+		Directives:   make(map[string]map[string]string),
+		Fields:       make(map[string][]Field),
+		TypeParams:   make(map[string]string),
+		Constructors: make(map[string][]string),
+		pkgDoc:       a.Doc.Text(),
+	}
+
+	for _, i := range a.Imports {
+		if i.Name != nil {
+			parsed.Imports = append(parsed.Imports, fmt.Sprintf("%s %s", i.Name.String(), i.Path.Value))
+		} else {
+			parsed.Imports = append(parsed.Imports, i.Path.Value)
+		}
+	}
+
+	unresolved := make(map[*ast.Ident]bool, len(a.Unresolved))
+	for _, id := range a.Unresolved {
+		unresolved[id] = true
+	}
+	declared := typeParamNames(a)
+	for _, d := range a.Decls {
+		if structName, fd := getReceiverTypeName(src, d); structName != "" {
+			// 私有方法
+			if !fd.Name.IsExported() {
+				continue
+			}
+			if _, ok := parsed.Methods[structName]; !ok {
+				parsed.Structs = append(parsed.Structs, structName)
+			}
+
+			m := makeMethod(src.renaming(fd, declared[structName]), fd)
+			m.unresolved = unresolvedIdents(fd.Type, unresolved)
+			if recv, err := getReceiverType(fd); err == nil {
+				_, m.PointerReceiver = recv.(*ast.StarExpr)
+			}
+			parsed.Methods[structName] = append(parsed.Methods[structName], m)
+		} else if fd, ok := d.(*ast.FuncDecl); ok && fd.Recv == nil && fd.Name.IsExported() {
+			if structName := constructedType(fd); structName != "" {
+				parsed.Constructors[structName] = append(parsed.Constructors[structName], fd.Name.Name)
+			}
+			if fd.Type.TypeParams == nil {
+				m := makeMethod(src, fd)
+				m.unresolved = unresolvedIdents(fd.Type, unresolved)
+				parsed.Functions = append(parsed.Functions, m)
+			}
+		}
+	}
+
+	for _, d := range a.Decls {
+		gd, ok := d.(*ast.GenDecl)
+		if !ok || gd.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gd.Specs {
+			ts := spec.(*ast.TypeSpec)
+			cg := ts.Doc
+			if cg == nil && len(gd.Specs) == 1 {
+				cg = gd.Doc
+			}
+			if directives := parseDirectives(cg); len(directives) > 0 {
+				parsed.Directives[ts.Name.Name] = directives
+			}
+			if st, ok := ts.Type.(*ast.StructType); ok {
+				parsed.Fields[ts.Name.Name] = parseFields(src, st)
+			}
+			if ts.TypeParams != nil {
+				parsed.TypeParams[ts.Name.Name] = src.slice(ts.TypeParams.Opening, ts.TypeParams.Closing+1)
+			}
+		}
+	}
+
+	if parsed.TypeDoc, err = typeDocs(a); err != nil {
+		return nil, err
+	}
+
+	return parsed, nil
+}
+
+// typeDocs returns the doc of every type declared in a. A panic of go/doc on
+// an unusual file is returned as an error.
+func typeDocs(a *ast.File) (docs map[string]string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("struct2interface: reading type docs: %v", r)
+		}
+	}()
+
+	docs = make(map[string]string)
+	for _, t := range doc.New(&ast.Package{Files: map[string]*ast.File{"": a}}, "", doc.AllDecls).Types {
+		docs[t.Name] = strings.TrimSuffix(stripDirectives(t.Doc), "\n")
+	}
+	return docs, nil
+}
+
+// stripDirectives drops from the doc text the "// struct2interface:"
+// directives, which go/doc only drops without the space.
+func stripDirectives(text string) string {
+	if !strings.Contains(text, directivePrefix) {
+		return text
+	}
+	var lines []string
+	for _, line := range strings.SplitAfter(text, "\n") {
+		if !strings.HasPrefix(line, directivePrefix) {
+			lines = append(lines, line)
+		}
+	}
+	// drop the blank line that separated a stripped directive
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "")
+}
+
+// constructedType returns the name of the type T of a function fd returning
+// a single *T, such as *Foo or *Repo[T].
+func constructedType(fd *ast.FuncDecl) string {
+	results := fd.Type.Results
+	if results == nil || len(results.List) != 1 || len(results.List[0].Names) > 1 {
+		return ""
+	}
+	star, ok := unparen(results.List[0].Type).(*ast.StarExpr)
+	if !ok {
+		return ""
+	}
+	t := unparen(star.X)
+	switch index := t.(type) {
+	case *ast.IndexExpr:
+		t = index.X
+	case *ast.IndexListExpr:
+		t = index.X
+	}
+	if ident, ok := t.(*ast.Ident); ok {
+		return ident.Name
+	}
+	return ""
+}
+
+// constructorNote returns the doc paragraph pointing to the constructors of
+// a struct, if any.
+func constructorNote(constructors []string) []string {
+	switch len(constructors) {
+	case 0:
+		return nil
+	case 1:
+		return []string{fmt.Sprintf("Use %s to construct a value satisfying this interface.", constructors[0])}
+	}
+	last := len(constructors) - 1
+	return []string{fmt.Sprintf("Use %s or %s to construct a value satisfying this interface.", strings.Join(constructors[:last], ", "), constructors[last])}
+}
+
+// parseFields returns the fields of st in declaration order.
+func parseFields(src source, st *ast.StructType) []Field {
+	var fields []Field
+	for _, f := range st.Fields.List {
+		t := src.slice(f.Type.Pos(), f.Type.End())
+		if len(f.Names) == 0 {
+			fields = append(fields, Field{Name: embeddedName(t), Type: t, Embedded: true})
+			continue
+		}
+		for _, n := range f.Names {
+			fields = append(fields, Field{Name: n.Name, Type: t})
+		}
+	}
+	return fields
+}
+
+// embeddedName returns the field name of an embedded type such as *pkg.T[int].
+func embeddedName(t string) string {
+	t = strings.TrimPrefix(t, "*")
+	if i := strings.Index(t, "["); i >= 0 {
+		t = t[:i]
+	}
+	if i := strings.LastIndex(t, "."); i >= 0 {
+		t = t[i+1:]
+	}
+	return t
+}
+
+const directivePrefix = "struct2interface:"
+
+// directive returns the text of comment after "//struct2interface:" or
+// "// struct2interface:", and whether it is such a directive.
+func directive(comment string) (string, bool) {
+	text, ok := strings.CutPrefix(comment, "//")
+	if !ok {
+		return "", false
+	}
+	return strings.CutPrefix(strings.TrimPrefix(text, " "), directivePrefix)
+}
+
+// isDirective reports whether comment is a //struct2interface: directive.
+func isDirective(comment string) bool {
+	_, ok := directive(comment)
+	return ok
+}
+
+// parseDirectives collects the //struct2interface:key=value directives of cg,
+// also written //struct2interface:key value.
+func parseDirectives(cg *ast.CommentGroup) map[string]string {
+	if cg == nil {
+		return nil
+	}
+	var directives map[string]string
+	for _, c := range cg.List {
+		text, ok := directive(c.Text)
+		if !ok {
+			continue
+		}
+		key, value := strings.TrimSpace(text), ""
+		if i := strings.IndexAny(key, "= \t"); i >= 0 {
+			key, value = key[:i], strings.TrimSpace(key[i+1:])
+		}
+		if directives == nil {
+			directives = make(map[string]string)
+		}
+		directives[key] = value
+	}
+	return directives
+}
+
+// parseBuildConstraint returns the //go:build expression of the file, if any.
+func parseBuildConstraint(a *ast.File) (string, error) {
+	for _, cg := range a.Comments {
+		if cg.Pos() > a.Package {
+			break
+		}
+		for _, c := range cg.List {
+			if !constraint.IsGoBuild(c.Text) {
+				continue
+			}
+			expr, err := constraint.Parse(c.Text)
+			if err != nil {
+				return "", err
+			}
+			return expr.String(), nil
+		}
+	}
+	return "", nil
+}
+
+// packageDoc returns the package doc comment of doc.go in dir, or else of
+// the first of files having one.
+func packageDoc(dir string, files []*ParsedFile) string {
+	a, err := parser.ParseFile(token.NewFileSet(), filepath.Join(dir, "doc.go"), nil, parser.PackageClauseOnly|parser.ParseComments)
+	if err == nil && a.Doc != nil {
+		return a.Doc.Text()
+	}
+	for _, f := range files {
+		if f.pkgDoc != "" {
+			return f.pkgDoc
+		}
+	}
+	return ""
+}
+
+// matchFile reports whether ctx selects file, whose content is src.
+func matchFile(ctx *build.Context, file string, src []byte) (bool, error) {
+	c := *ctx
+	c.OpenFile = func(string) (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(src)), nil
+	}
+	return c.MatchFile(filepath.Dir(file), filepath.Base(file))
+}
+
+// joinBuildConstraints ANDs together the distinct constraints of the files
+// contributing to one generated file.
+func joinBuildConstraints(constraints []string) (string, error) {
+	var (
+		expr constraint.Expr
+		seen = make(map[string]struct{})
+	)
+	for _, c := range constraints {
+		if _, ok := seen[c]; ok || c == "" {
+			continue
+		}
+		seen[c] = struct{}{}
+		e, err := constraint.Parse("//go:build " + c)
+		if err != nil {
+			return "", err
+		}
+		if expr == nil {
+			expr = e
+		} else {
+			expr = &constraint.AndExpr{X: expr, Y: e}
+		}
+	}
+	if expr == nil {
+		return "", nil
+	}
+	return expr.String(), nil
+}
+
+func formatCode(code string) ([]byte, error) {
+	opts := &imports.Options{
+		TabIndent: true,
+		TabWidth:  2,
+		Fragment:  true,
+		Comments:  true,
+	}
+
+	formatcode, err := imports.Process("", []byte(code), opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if string(formatcode) == code {
+		return formatcode, err
+	}
+
+	return formatCode(string(formatcode))
+}
+
+const (
+	generatedHeader  = "// Code generated by struct2interface; DO NOT EDIT."
+	sourceHashPrefix = "// source-hash: "
+)
+
+// isGenerated reports whether src has the header of a file written by
+// struct2interface, scanning only the lines before the package clause.
+func isGenerated(src []byte) bool {
+	scanner := bufio.NewScanner(bytes.NewReader(src))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == generatedHeader {
+			return true
+		}
+		if strings.HasPrefix(line, "package ") {
+			return false
+		}
+	}
+	return false
+}
+
+// storedSourceHash returns the source hash recorded in the header of the
+// generated file path, or "" if there is none.
+func storedSourceHash(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if hash, ok := strings.CutPrefix(line, sourceHashPrefix); ok {
+			return hash, nil
+		}
+		if strings.HasPrefix(line, "package ") {
+			break
+		}
+	}
+	return "", scanner.Err()
+}
+
+// sourceHash returns the combined hash of the source files behind one
+// generated file.
+func sourceHash(files []*ParsedFile) string {
+	h := sha256.New()
+	for _, f := range files {
+		h.Write(f.srcHash)
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil))
+}
+
+// missingImports returns the imports of the generated content that cannot be
+// resolved from dir. Modules are never downloaded.
+func missingImports(dir string, content []byte) ([]string, error) {
+	f, err := parser.ParseFile(token.NewFileSet(), "", content, parser.ImportsOnly)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, i := range f.Imports {
+		path, err := strconv.Unquote(i.Path.Value)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	if len(paths) == 0 {
+		return nil, nil
+	}
+
+	cmd := exec.Command("go", append([]string{"list", "-mod=readonly", "-e", "-find", "-f", "{{if .Error}}{{.ImportPath}}{{end}}"}, paths...)...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(string(out)), nil
+}
+
+func makeInterfaceHead(pkgName string, pkgDoc string, buildConstraint string, srcHash string, imports []string) []string {
+	output := []string{
+		generatedHeader,
+	}
+	if srcHash != "" {
+		output = append(output, sourceHashPrefix+srcHash)
+	}
+	output = append(output, "")
+	if buildConstraint != "" {
+		output = append(output, "//go:build "+buildConstraint, "")
+	}
+	if pkgDoc != "" {
+		output = append(output, "// "+strings.Replace(strings.TrimSuffix(pkgDoc, "\n"), "\n", "\n// ", -1))
+	}
+	output = append(output,
+		"package "+pkgName,
+		"import (",
+	)
+	output = append(output, imports...)
+	output = append(output,
+		")",
+		"",
+	)
+	return output
+}
+
+func makeInterfaceBody(output []string, iface string, typeParams string, typeDoc string, notes []string, methods []string, opts Options) []string {
+	comment := iface + opts.structDocSuffix() + typeDoc
+	comment = strings.TrimSuffix(strings.Replace(comment, "\n", "\n//\t", -1), "\n//\t")
+	// gofmt requires a blank comment line before an indented block
+	comment = strings.Replace(comment, "\n//\t", "\n//\n//\t", 1)
+	if len(strings.TrimSpace(comment)) > 0 {
+		output = append(output, fmt.Sprintf("// %s", comment))
+	}
+	// notes are paragraphs of their own after the doc
+	for _, note := range notes {
+		if len(strings.TrimSpace(comment)) > 0 {
+			output = append(output, "//")
+		}
+		output = append(output, "// "+note)
+	}
+
+	output = append(output, fmt.Sprintf("type %s%s interface {", iface, typeParams))
+	output = append(output, methods...)
+	output = append(output, "}")
+	return output
+}
+
+// makeAlias appends a type alias for the interface iface, as requested by
+// the alias= directive.
+func makeAlias(output []string, alias string, iface string) []string {
+	return append(output,
+		"",
+		fmt.Sprintf("// %s is an alias of %s.", alias, iface),
+		fmt.Sprintf("type %s = %s", alias, iface),
+	)
+}
+
+// contractsPkg is the package GenContractsPackage writes interfaces to.
+const contractsPkg = "contracts"
+
+// makeContractsCompliance appends assertions that every struct implements
+// its interface in the contracts package.
+func makeContractsCompliance(output []string, structs []string, opts Options) []string {
+	output = append(output, "var (")
+	for _, structName := range structs {
+		output = append(output, fmt.Sprintf("_ %s.%s = (*%s)(nil)", contractsPkg, opts.interfaceName(structName), structName))
+	}
+	return append(output, ")")
+}
+
+// makeAssertions appends compile-time assertions that structs implement
+// their interface.
+func makeAssertions(output []string, structs []string, opts Options) []string {
+	if len(structs) == 0 {
+		return output
+	}
+	output = append(output, "", "var (")
+	for _, structName := range structs {
+		output = append(output, fmt.Sprintf("_ %s = (*%s)(nil)", opts.interfaceName(structName), structName))
+	}
+	return append(output, ")")
+}
+
+// importPath returns the import path of the package in dir.
+func importPath(dir string) (string, error) {
+	cmd := exec.Command("go", "list", "-mod=readonly", "-e", "-find", "-f", "{{.ImportPath}}", ".")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// makeComplianceTest returns a test file asserting that every struct still
+// implements its generated interface.
+func makeComplianceTest(pkgName string, buildConstraint string, structs []string, opts Options) []string {
+	output := makeInterfaceHead(pkgName, "", buildConstraint, "", []string{`"testing"`})
+	for _, structName := range structs {
+		iface := opts.interfaceName(structName)
+		output = append(output,
+			fmt.Sprintf("func Test%sImplements%s(t *testing.T) {", structName, iface),
+			fmt.Sprintf("var _ %s = (*%s)(nil)", iface, structName),
+			"}",
+			"",
+		)
+	}
+	return output
+}
+
+// renderCode formats code and applies the PostProcess hook.
+func renderCode(code []string, opts Options) ([]byte, error) {
+	var (
+		src    = []byte(strings.Join(code, "\n"))
+		result []byte
+		err    error
+	)
+	switch {
+	case opts.DisableFormatting || opts.NoFormatting:
+		result = src
+	case opts.FastFormat && canFastFormat(src):
+		result, err = format.Source(src)
+	default:
+		result, err = formatCode(string(src))
+	}
+	if err != nil {
+		opts.logger().Error("formatting failed", "error", err)
+		return nil, err
+	}
+	if opts.PostProcess != nil {
+		if result, err = opts.PostProcess(result); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// writeFile writes content to fileName, or passes it to WriteHook, creating
+// its directory unless disabled. The content goes to a temporary file of the
+// same directory that is then renamed, so fileName is never left partially
+// written.
+func writeFile(fileName string, content []byte, opts Options) (err error) {
+	if opts.WriteHook != nil {
+		return opts.WriteHook(fileName, content)
+	}
+	if !opts.DisableMkdirAll {
+		if err := os.MkdirAll(filepath.Dir(fileName), 0755); err != nil {
+			return err
+		}
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(fileName), "."+filepath.Base(fileName)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+	if _, err = tmp.Write(content); err != nil {
+		return err
+	}
+	if err = tmp.Chmod(opts.writePermissions()); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), fileName)
+}
+
+// interfaceView is one generated interface file, holding the structs whose
+// interfaces are written to it.
+type interfaceView struct {
+	name     string
+	fileName string
+	pkgName  string
+	structs  []string
+	// local views are in the source package and also get the builders,
+	// wrappers and other generated helpers of their structs.
+	local bool
+}
+
+// embeddedInterfaces returns the interfaces, among those of structs, of the
+// non-generic structs embedded in fields.
+func embeddedInterfaces(fields []Field, structs []string, typeParams map[string]string, opts Options) []string {
+	var embeds []string
+	for _, f := range fields {
+		name := strings.TrimPrefix(f.Type, "*")
+		if !f.Embedded || typeParams[name] != "" {
+			continue
+		}
+		for _, structName := range structs {
+			if structName == name {
+				embeds = append(embeds, opts.interfaceName(name))
+			}
+		}
+	}
+	return embeds
+}
+
+// nonGeneric returns the structs without type parameters, the only ones
+// builders, mocks, aliases and compliance tests are generated for.
+func nonGeneric(structs []string, typeParams map[string]string) []string {
+	var concrete []string
+	for _, structName := range structs {
+		if typeParams[structName] == "" {
+			concrete = append(concrete, structName)
+		}
+	}
+	return concrete
+}
+
+// structOutputs returns the output-<view>=<dir> directives of a struct,
+// sorted by view name.
+func structOutputs(directives map[string]string) []string {
+	var views []string
+	for key := range directives {
+		if strings.HasPrefix(key, "output-") {
+			views = append(views, strings.TrimPrefix(key, "output-"))
+		}
+	}
+	sort.Strings(views)
+	return views
+}
+
+// viewMethods returns the methods that belong in the named view. Methods
+// tagged //struct2interface:only=<view>[,<view>] are limited to those views;
+// the default view, named "", always gets every method.
+func viewMethods(methods []Method, view string) []Method {
+	if view == "" {
+		return methods
+	}
+	var result []Method
+	for _, m := range methods {
+		only, ok := m.Directives["only"]
+		if !ok {
+			result = append(result, m)
+			continue
+		}
+		for _, v := range strings.Split(only, ",") {
+			if strings.TrimSpace(v) == view {
+				result = append(result, m)
+				break
+			}
+		}
+	}
+	return result
+}
+
+// generatedFile is one rendered output file.
+type generatedFile struct {
+	path    string
+	content []byte
+	elapsed time.Duration
+}
+
+// generateFiles renders the output files for objs without writing them.
+func generateFiles(objs map[string][]*ParsedFile, opts Options) ([]generatedFile, error) {
+	return generateChangedFiles(objs, opts, nil)
+}
+
+// generateChangedFiles is generateFiles leaving out the files of the
+// packages whose interface files all record their source hash, as reported
+// by unchanged, when it is non-nil and SourceHash is set.
+func generateChangedFiles(objs map[string][]*ParsedFile, opts Options, unchanged func(path, srcHash string) bool) ([]generatedFile, error) {
+	var dirs []string
+	for dir := range objs {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+
+	switch opts.MethodGrouping {
+	case "", "none", "alphabetical", "prefix":
+	default:
+		return nil, fmt.Errorf("struct2interface: unknown MethodGrouping %q", opts.MethodGrouping)
+	}
+	switch opts.MethodSet {
+	case "", "all", "pointer", "value":
+	default:
+		return nil, fmt.Errorf("struct2interface: unknown MethodSet %q", opts.MethodSet)
+	}
+	tmpl, err := parseInterfaceTemplate(opts)
+	if err != nil {
+		return nil, err
+	}
+	switch opts.GroupBy {
+	case "", "package", "struct":
+	case "directory":
+		return nil, errors.New("struct2interface: GroupBy \"directory\" is not supported, a file holds a single package")
+	case "custom":
+		if opts.GroupByFunc == nil {
+			return nil, errors.New("struct2interface: GroupBy \"custom\" requires GroupByFunc")
+		}
+	default:
+		return nil, fmt.Errorf("struct2interface: unknown GroupBy %q", opts.GroupBy)
+	}
+	if opts.PerStructFile && opts.GroupBy != "" && opts.GroupBy != "struct" {
+		return nil, fmt.Errorf("struct2interface: PerStructFile conflicts with GroupBy %q", opts.GroupBy)
+	}
+	if opts.GenMockRegistry && !opts.GenTestMock {
+		return nil, errors.New("struct2interface: GenMockRegistry requires GenTestMock")
+	}
+	if g := opts.implementation(); opts.EmbedParentInterface && g != "" {
+		// the generated implementations lack the embedded interfaces
+		return nil, fmt.Errorf("struct2interface: EmbedParentInterface cannot be combined with %s", g)
+	}
+	if err := checkOutputDir(opts); err != nil {
+		return nil, err
+	}
+	base, baseImport, err := opts.baseInterface()
+	if err != nil {
+		return nil, err
+	}
+
+	var groups []sourceGroup
+	for _, dir := range dirs {
+		groups = append(groups, splitTestFiles(dir, objs[dir])...)
+	}
+
+	var (
+		files []generatedFile
+		// breakerState records the directories and packages that already
+		// declare the shared circuit breaker state, which test files share
+		breakerState = make(map[string]bool)
+		// outputSources records the directory each OutputDir file is
+		// generated from
+		outputSources = make(map[string]string)
+	)
+	for _, group := range groups {
+		dir, obj, test := group.dir, group.files, group.test
+		if len(obj) == 0 {
+			continue
+		}
+		opts := opts.withDirectiveNames(obj)
+
+		var (
+			startTime         = time.Now()
+			firstObj          = obj[0]
+			pkgName           = firstObj.PkgName
+			typeDoc           = make(map[string]string)
+			directives        = make(map[string]map[string]string)
+			fields            = make(map[string][]Field)
+			typeParams        = make(map[string]string)
+			constructors      = make(map[string][]string)
+			functions         = make([]Method, 0)
+			mapStructMethods  = make(map[string][]Method)
+			listStructMethods = make([]string, 0)
+			methodFiles       = make(map[string]map[string]string)
+			structAllImports  = make([]string, 0)
+			constraints       = make([]string, 0)
+		)
+
+		for _, file := range obj {
+			constraints = append(constraints, file.Constraint)
+			for typeName, doc := range file.TypeDoc {
+				if _, ok := typeDoc[typeName]; !ok {
+					typeDoc[typeName] = doc
+				}
+			}
+			for structName, d := range file.Directives {
+				directives[structName] = d
+			}
+			for structName, f := range file.Fields {
+				fields[structName] = f
+			}
+			for structName, p := range file.TypeParams {
+				typeParams[structName] = p
+			}
+			for structName, c := range file.Constructors {
+				constructors[structName] = append(constructors[structName], c...)
+			}
+			functions = append(functions, file.Functions...)
+			for _, structName := range file.Structs {
+				if _, ok := mapStructMethods[structName]; !ok {
+					listStructMethods = append(listStructMethods, structName)
+					methodFiles[structName] = make(map[string]string)
+				}
+				for _, m := range file.Methods[structName] {
+					if first, ok := methodFiles[structName][m.Name]; ok && opts.OnDuplicateMethod != nil {
+						if err := opts.OnDuplicateMethod(structName, m.Name, first, file.Path); err != nil {
+							return nil, err
+						}
+						continue
+					}
+					if _, ok := methodFiles[structName][m.Name]; !ok {
+						methodFiles[structName][m.Name] = file.Path
+					}
+					mapStructMethods[structName] = append(mapStructMethods[structName], m)
+				}
+
+				structAllImports = append(structAllImports, file.Imports...)
+			}
+		}
+
+		if baseImport != "" {
+			structAllImports = append(structAllImports, baseImport)
+		}
+		structAllImports = dedupImports(structAllImports)
+
+		buildConstraint, err := joinBuildConstraints(append(constraints, opts.BuildTags...))
+		if err != nil {
+			return nil, err
+		}
+
+		// checkedNames orders checked so that the first error is the same
+		// on every run
+		var (
+			checked      = make(map[string][]Method)
+			checkedNames = append([]string(nil), listStructMethods...)
+		)
+		for _, structName := range listStructMethods {
+			checked[structName] = mapStructMethods[structName]
+		}
+		if len(functions) > 0 {
+			checked[opts.functionsInterfaceName(pkgName)] = functions
+			checkedNames = append(checkedNames, opts.functionsInterfaceName(pkgName))
+		}
+		if err = checkImports(structAllImports, checked); err != nil {
+			return nil, err
+		}
+		if err = checkUnresolved(opts, dir, structAllImports, checked); err != nil {
+			return nil, err
+		}
+		var (
+			outDir      = dir
+			outPkg      = pkgName
+			localImport string
+		)
+		if opts.OutputDir != "" {
+			outDir, outPkg = opts.OutputDir, opts.outputPackage()
+			var (
+				qualified bool
+				declared  = sourceTypes(opts, dir, typeDoc)
+			)
+			for _, name := range checkedNames {
+				q, err := qualifyMethods(checked[name], pkgName, declared)
+				if err != nil {
+					return nil, err
+				}
+				qualified = qualified || q
+			}
+			if qualified {
+				if localImport, err = sourceImport(dir, pkgName); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if tmpl != nil {
+			for _, name := range checkedNames {
+				if err := applyInterfaceTemplate(tmpl, checked[name]); err != nil {
+					return nil, err
+				}
+			}
+		}
+		var (
+			omitted          = make(map[string][]string)
+			omittedFunctions []string
+		)
+		if opts.MaxMethodLength > 0 {
+			for _, structName := range listStructMethods {
+				mapStructMethods[structName], omitted[structName] = omitLongMethods(mapStructMethods[structName], opts.MaxMethodLength)
+			}
+			functions, omittedFunctions = omitLongMethods(functions, opts.MaxMethodLength)
+		}
+
+		var fileName = filepath.Join(outDir, opts.outputFileName(pkgName))
+		if test {
+			fileName = testFileName(fileName)
+		}
+		if opts.PathMapper != nil {
+			fileName = opts.PathMapper(firstObj.Path, dir)
+		}
+		if opts.OutputDir != "" && opts.PathMapper == nil {
+			// packages of the same name would overwrite each other
+			if other, ok := outputSources[fileName]; ok && other != dir {
+				return nil, fmt.Errorf("struct2interface: %s and %s both write %s to OutputDir", other, dir, fileName)
+			}
+			outputSources[fileName] = dir
+		}
+
+		var srcHash string
+		if opts.SourceHash {
+			srcHash = sourceHash(obj)
+		}
+
+		var (
+			defaultView = &interfaceView{fileName: fileName, pkgName: outPkg, local: true}
+			views       = []*interfaceView{defaultView}
+			viewByDir   = make(map[string]*interfaceView)
+			viewByFile  = map[string]*interfaceView{fileName: defaultView}
+			contracts   []string
+			local       []string
+		)
+		for _, structName := range listStructMethods {
+			outputs := structOutputs(directives[structName])
+			if len(outputs) == 0 && opts.GenContractsPackage && !test {
+				contracts = append(contracts, structName)
+				continue
+			}
+			if len(outputs) == 0 {
+				groupFile := opts.groupFileName(structName, pkgName, outDir, fileName)
+				if test && groupFile != fileName && opts.GroupBy != "custom" {
+					groupFile = testFileName(groupFile)
+				}
+				view, ok := viewByFile[groupFile]
+				if !ok {
+					view = &interfaceView{fileName: groupFile, pkgName: outPkg, local: true}
+					viewByFile[groupFile] = view
+					views = append(views, view)
+				}
+				view.structs = append(view.structs, structName)
+				local = append(local, structName)
+				continue
+			}
+			for _, name := range outputs {
+				viewDir := filepath.Join(dir, directives[structName]["output-"+name])
+				view, ok := viewByDir[viewDir]
+				if !ok {
+					view = &interfaceView{
+						name:     name,
+						fileName: filepath.Join(viewDir, "interface_"+filepath.Base(viewDir)+".go"),
+						pkgName:  filepath.Base(viewDir),
+					}
+					viewByDir[viewDir] = view
+					views = append(views, view)
+				}
+				view.structs = append(view.structs, structName)
+			}
+		}
+
+		if unchanged != nil && srcHash != "" {
+			fresh := true
+			for _, view := range views {
+				if len(view.structs) > 0 || (view == defaultView && len(functions) > 0) {
+					fresh = fresh && unchanged(view.fileName, srcHash)
+				}
+			}
+			if fresh {
+				// the state is declared by the skipped files
+				for _, view := range views {
+					if view.local && opts.GenCircuitBreaker && len(nonGeneric(view.structs, typeParams)) > 0 {
+						breakerState[filepath.Dir(view.fileName)+" "+view.pkgName] = true
+					}
+				}
+				opts.logger().Debug("skipping package with unchanged source hash", "dir", dir)
+				continue
+			}
+		}
+
+		for _, view := range views {
+			if len(view.structs) == 0 && (view != defaultView || len(functions) == 0) {
+				continue
+			}
+
+			var (
+				structMethods = mapStructMethods
+				srcImport     = localImport
+			)
+			if !view.local && opts.OutputDir == "" {
+				// views in another directory are in another package
+				structMethods, srcImport, err = qualifyView(view.structs, mapStructMethods, dir, pkgName, sourceTypes(opts, dir, typeDoc))
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			imports := structAllImports
+			if view.local && opts.GenOTelTracer {
+				imports = append(imports[:len(imports):len(imports)], tracerImports...)
+			}
+			if srcImport != "" {
+				imports = append(imports[:len(imports):len(imports)], srcImport)
+			}
+			pkgDoc := opts.PackageComment
+			if pkgDoc == "" && opts.IncludePackageDoc && view.pkgName == pkgName && !test {
+				pkgDoc = packageDoc(dir, obj)
+			}
+			output := makeInterfaceHead(view.pkgName, pkgDoc, buildConstraint, srcHash, imports)
+			for _, structName := range view.structs {
+				methods := groupMethodLines(viewMethods(structMethods[structName], view.name), opts.MethodGrouping)
+				methods = append(methods, omitted[structName]...)
+				if opts.EmbedParentInterface {
+					methods = append(embeddedInterfaces(fields[structName], view.structs, typeParams, opts), methods...)
+				}
+				methods = embedBase(methods, opts.interfaceName(structName), base)
+				var notes []string
+				if view.local {
+					notes = constructorNote(constructors[structName])
+				}
+				output = makeInterfaceBody(output, opts.interfaceName(structName), typeParams[structName], typeDoc[structName], notes, methods, opts)
+				if alias := directives[structName]["alias"]; alias != "" && typeParams[structName] == "" {
+					output = makeAlias(output, alias, opts.interfaceName(structName))
+				}
+			}
+			if view == defaultView && len(functions) > 0 {
+				methods := append(groupMethodLines(functions, opts.MethodGrouping), omittedFunctions...)
+				output = makeInterfaceBody(output, opts.functionsInterfaceName(pkgName), "", "", nil, embedBase(methods, opts.functionsInterfaceName(pkgName), base), opts)
+			}
+			if view.local {
+				for _, structName := range nonGeneric(view.structs, typeParams) {
+					if opts.GenBuilder && isStructType(structName, fields, typeDoc) {
+						output = makeBuilder(output, structName, fields[structName], opts)
+					}
+					if opts.GenDispatcher {
+						output = makeDispatcher(output, structName, mapStructMethods[structName], opts)
+					}
+					if opts.GenFunctionalOptions && isStructType(structName, fields, typeDoc) {
+						output = makeFunctionalOptions(output, structName, mapStructMethods[structName])
+					}
+					if opts.GenCircuitBreaker {
+						output = makeCircuitBreaker(output, structName, mapStructMethods[structName], opts)
+					}
+					if opts.GenRetry {
+						output = makeRetry(output, structName, mapStructMethods[structName], opts)
+					}
+					if opts.GenOTelTracer {
+						output = makeTracer(output, structName, mapStructMethods[structName], opts)
+					}
+					if opts.GenCache {
+						output = makeCache(output, structName, mapStructMethods[structName], opts)
+					}
+					if opts.GenTimeoutWrapper {
+						output = makeTimeoutWrapper(output, structName, mapStructMethods[structName], opts)
+					}
+					if opts.GenChangeDetector {
+						output = makeChangeDetector(output, structName, mapStructMethods[structName], opts)
+					}
+				}
+				if opts.EmitAssertions && view.pkgName == pkgName {
+					output = makeAssertions(output, nonGeneric(view.structs, typeParams), opts)
+				}
+				// the shared circuit breaker state is declared once per package
+				statePkg := filepath.Dir(view.fileName) + " " + view.pkgName
+				if opts.GenCircuitBreaker && !breakerState[statePkg] && len(nonGeneric(view.structs, typeParams)) > 0 {
+					output = makeCircuitBreakerState(output)
+					breakerState[statePkg] = true
+				}
+			}
+
+			content, err := renderCode(output, opts)
+			if err != nil {
+				return nil, err
+			}
+			if opts.ErrorOnMissingImport {
+				missing, err := missingImports(dir, content)
+				if err != nil {
+					return nil, err
+				}
+				if len(missing) > 0 {
+					return nil, fmt.Errorf("%s: cannot resolve imports %s", view.fileName, strings.Join(missing, ", "))
+				}
+			}
+			files = append(files, generatedFile{path: view.fileName, content: content, elapsed: time.Since(startTime)})
+		}
+
+		if len(contracts) > 0 {
+			for _, structName := range contracts {
+				iface := opts.interfaceName(structName)
+				output := makeInterfaceHead(contractsPkg, opts.PackageComment, buildConstraint, srcHash, structAllImports)
+				methods := append(groupMethodLines(mapStructMethods[structName], opts.MethodGrouping), omitted[structName]...)
+				output = makeInterfaceBody(output, iface, typeParams[structName], typeDoc[structName], nil, embedBase(methods, iface, base), opts)
+				content, err := renderCode(output, opts)
+				if err != nil {
+					return nil, err
+				}
+				files = append(files, generatedFile{path: filepath.Join(dir, contractsPkg, iface+".go"), content: content, elapsed: time.Since(startTime)})
+			}
+
+			if concrete := nonGeneric(contracts, typeParams); len(concrete) > 0 {
+				pkgPath, err := importPath(dir)
+				if err != nil {
+					return nil, err
+				}
+				output := makeInterfaceHead(pkgName, "", buildConstraint, "", []string{strconv.Quote(pkgPath + "/" + contractsPkg)})
+				output = makeContractsCompliance(output, concrete, opts)
+				content, err := renderCode(output, opts)
+				if err != nil {
+					return nil, err
+				}
+				files = append(files, generatedFile{path: filepath.Join(dir, "compliance.go"), content: content, elapsed: time.Since(startTime)})
+			}
+		}
+
+		// the test files of the package would overwrite those of its sources
+		if test {
+			continue
+		}
+
+		concrete := nonGeneric(local, typeParams)
+		if opts.GenComplianceTest && len(concrete) > 0 {
+			testFileName := filepath.Join(dir, "interface_compliance_test.go")
+			content, err := renderCode(makeComplianceTest(pkgName, buildConstraint, concrete, opts), opts)
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, generatedFile{path: testFileName, content: content, elapsed: time.Since(startTime)})
+		}
+
+		if opts.GenTestMock && len(concrete) > 0 {
+			output := makeInterfaceHead(pkgName, "", buildConstraint, srcHash, structAllImports)
+			for _, structName := range concrete {
+				output = makeTestMock(output, structName, mapStructMethods[structName], opts)
+			}
+			content, err := renderCode(output, opts)
+			if err != nil {
+				return nil, err
+			}
+			mockFileName := filepath.Join(dir, "interface_"+pkgName+"_mock_test.go")
+			files = append(files, generatedFile{path: mockFileName, content: content, elapsed: time.Since(startTime)})
+
+			if opts.GenMockRegistry {
+				content, err := renderCode(makeMockRegistry(pkgName, buildConstraint, concrete, opts), opts)
+				if err != nil {
+					return nil, err
+				}
+				registryFileName := filepath.Join(dir, "mock_registry_test.go")
+				files = append(files, generatedFile{path: registryFileName, content: content, elapsed: time.Since(startTime)})
+			}
+		}
+	}
+
+	return files, nil
+}
+
+// multiError collects the errors of files that were skipped.
+type multiError []error
+
+func (e multiError) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// beforeWrite applies the BeforeWrite hook to f.
+func beforeWrite(f generatedFile, opts Options) ([]byte, error) {
+	if opts.BeforeWrite == nil {
+		return f.content, nil
+	}
+	content, err := opts.BeforeWrite(f.path, f.content)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", f.path, err)
+	}
+	return content, nil
+}
+
+// createFile writes the files generated for objs and returns their absolute
+// paths.
+func createFile(objs map[string][]*ParsedFile, opts Options) ([]string, error) {
+	files, err := generateFiles(objs, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		written []string
+		skipped multiError
+	)
+	for _, f := range files {
+		content, err := beforeWrite(f, opts)
+		if err != nil {
+			skipped = append(skipped, err)
+			continue
+		}
+		if err = writeFile(f.path, content, opts); err != nil {
+			return written, fmt.Errorf("struct2interface: processing %s: %w", f.path, err)
+		}
+		opts.logger().Info("wrote interface file", "file", f.path, "duration", f.elapsed)
+		path, err := filepath.Abs(f.path)
+		if err != nil {
+			return written, err
+		}
+		written = append(written, path)
+	}
+	if len(skipped) > 0 {
+		return written, skipped
+	}
+	return written, nil
+}
+
+// stripReturnNames removes the result names from the signature of methods.
+func stripReturnNames(methods []Method) {
+	for i, m := range methods {
+		if len(m.Results) == 0 || m.Results[0].Name == "" {
+			continue
+		}
+		results := make([]Param, len(m.Results))
+		for j, r := range m.Results {
+			results[j] = Param{Type: r.Type}
+		}
+		methods[i].Results = results
+		methods[i].Code = fmt.Sprintf("%s(%s)%s", m.Name, m.params, resultList(results))
+	}
+}
+
+// omitLongMethods splits off the methods whose signature has more than max
+// characters, returning the comments replacing them in the interface.
+func omitLongMethods(methods []Method, max int) ([]Method, []string) {
+	var (
+		kept     []Method
+		comments []string
+	)
+	for _, m := range methods {
+		if utf8.RuneCountInString(m.Code) > max {
+			comments = append(comments, fmt.Sprintf("// Method %s omitted: signature exceeds max length", m.Name))
+			continue
+		}
+		kept = append(kept, m)
+	}
+	return kept, comments
+}
+
+// methodLines returns the interface body lines of methods.
+func methodLines(methods []Method) []string {
+	var lines []string
+	for _, m := range methods {
+		lines = append(lines, m.Lines()...)
+	}
+	return lines
+}
+
+func makeFile(fset *token.FileSet, file string, opts Options) (*ParsedFile, error) {
+	src, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return makeSource(fset, file, src, opts)
+}
+
+// makeSource is makeFile for the already read source src of file.
+func makeSource(fset *token.FileSet, file string, src []byte, opts Options) (*ParsedFile, error) {
+	var (
+		allImports = make([]string, 0)
+		iset       = make(map[string]struct{})
+		err        error
+	)
+
+	// generated output under a name skipFile does not catch
+	if isGenerated(src) {
+		opts.logger().Debug("skipping generated file", "file", file)
+		return nil, nil
+	}
+
+	if ok, err := matchFile(opts.buildContext(), file, src); err != nil {
+		return nil, err
+	} else if !ok {
+		opts.logger().Debug("skipping file excluded by the build context", "file", file)
+		return nil, nil
+	}
+
+	srcHash := sha256.Sum256(src)
+
+	if opts.PreProcess != nil {
+		if src, err = opts.PreProcess(src); err != nil {
+			return nil, err
+		}
+	}
+	for _, p := range opts.FileProcessors {
+		if src, err = p.Process(file, src); err != nil {
+			return nil, err
+		}
+	}
+
+	parsed, err := parseStructFileSet(fset, file, src)
+	if err != nil {
+		opts.logger().Error("parsing failed", "file", file, "error", err)
+		return nil, err
+	}
+	// a previous output under a custom name
+	if opts.OutputFileName != nil && filepath.Base(file) == opts.OutputFileName(parsed.PkgName) {
+		return nil, nil
+	}
+
+	for structName := range parsed.Methods {
+		name, named := parsed.Directives[structName]["name"]
+		if named && name != "-" && !token.IsIdentifier(name) {
+			return nil, fmt.Errorf("%s: invalid interface name %q for %s", file, name, structName)
+		}
+		if !opts.includeStruct(structName) || opts.interfaceName(structName) == "" || name == "-" ||
+			(opts.StructFilter != nil && !opts.StructFilter(structName)) {
+			delete(parsed.Methods, structName)
+		}
+	}
+	if opts.MethodSet == "pointer" || opts.MethodSet == "value" {
+		filterMethods(parsed, func(structName string, m Method) bool {
+			return m.PointerReceiver == (opts.MethodSet == "pointer") || opts.keepWellKnown(m)
+		})
+	}
+	if opts.SkipDeprecated {
+		filterMethods(parsed, func(structName string, m Method) bool {
+			return !m.Deprecated() || opts.keepWellKnown(m)
+		})
+	}
+	if dropCgo(parsed) {
+		var strictErr error
+		filterMethods(parsed, func(structName string, m Method) bool {
+			if cgoIdent.MatchString(m.Code) {
+				if err := opts.warn("skipping method using cgo types", "file", file, "struct", structName, "method", m.Name); err != nil && strictErr == nil {
+					strictErr = err
+				}
+				return false
+			}
+			return true
+		})
+		if strictErr != nil {
+			return nil, strictErr
+		}
+	}
+	if opts.StripReturnNames {
+		for _, methods := range parsed.Methods {
+			stripReturnNames(methods)
+		}
+		stripReturnNames(parsed.Functions)
+	}
+	if len(opts.MethodAnnotations) > 0 {
+		for structName, methods := range parsed.Methods {
+			for i, m := range methods {
+				var annotations []string
+				annotations = append(annotations, opts.MethodAnnotations[m.Name]...)
+				annotations = append(annotations, opts.MethodAnnotations[structName+"."+m.Name]...)
+				if len(annotations) > 0 {
+					methods[i].Docs = append(annotations, m.Docs...)
+				}
+			}
+		}
+	}
+	if !opts.IncludeFunctions || !opts.includeStruct(parsed.PkgName) {
+		parsed.Functions = nil
+	}
+	if len(parsed.Methods) == 0 && len(parsed.Functions) == 0 {
+		return nil, nil
+	}
+
+	var structs []string
+	for _, structName := range parsed.Structs {
+		if _, ok := parsed.Methods[structName]; ok {
+			structs = append(structs, structName)
+		}
+	}
+
+	for _, i := range parsed.Imports {
+		if strings.HasPrefix(i, ". ") {
+			if opts.ForbidDotImports {
+				return nil, fmt.Errorf("%s: dot import %s is forbidden", file, strings.TrimPrefix(i, ". "))
+			}
+			if err := opts.warn("dot import, the interface uses its identifiers unqualified", "file", file, "import", strings.TrimPrefix(i, ". ")); err != nil {
+				return nil, err
+			}
+		}
+		if _, ok := iset[i]; !ok {
+			allImports = append(allImports, i)
+			iset[i] = struct{}{}
+		}
+	}
+
+	parsed.Path = file
+	parsed.srcHash = srcHash[:]
+	parsed.DirPath = filepath.Dir(file)
+	parsed.Structs = structs
+	parsed.Imports = allImports
 
-func (m *Method) Lines() []string {
-	var lines []string
-	lines = append(lines, m.Docs...)
-	lines = append(lines, m.Code)
-	return lines
+	var methods int
+	for _, m := range parsed.Methods {
+		methods += len(m)
+	}
+	opts.logger().Debug("parsed file", "file", file, "pkg", parsed.PkgName, "methods_count", methods)
+	return parsed, nil
 }
 
-func getReceiverTypeName(src []byte, fl interface{}) (string, *ast.FuncDecl) {
-	fd, ok := fl.(*ast.FuncDecl)
-	if !ok {
-		return "", nil
+// dedupImports keeps the first import of every path and name. A path
+// imported under different names is kept once per name, since the methods
+// of each file refer to it by the name of their file. The imports of all
+// files are deduplicated together, as makeFile only does so per file.
+func dedupImports(imports []string) []string {
+	type key struct{ name, path string }
+	var (
+		kept []string
+		seen = make(map[key]bool)
+	)
+	for _, i := range imports {
+		var k key
+		if fields := strings.Fields(i); len(fields) == 2 {
+			k = key{fields[0], fields[1]}
+		} else {
+			k.path = i
+			if p, err := strconv.Unquote(i); err == nil {
+				k.name = assumedPackageName(p)
+			}
+		}
+		if !seen[k] {
+			seen[k] = true
+			kept = append(kept, i)
+		}
 	}
-	t, err := getReceiverType(fd)
-	if err != nil {
-		return "", nil
+	return kept
+}
+
+// filterMethods keeps only the methods of parsed for which keep returns true,
+// dropping structs that are left without methods.
+func filterMethods(parsed *ParsedFile, keep func(structName string, m Method) bool) {
+	for structName, methods := range parsed.Methods {
+		var kept []Method
+		for _, m := range methods {
+			if keep(structName, m) {
+				kept = append(kept, m)
+			}
+		}
+		if len(kept) == 0 {
+			delete(parsed.Methods, structName)
+		} else {
+			parsed.Methods[structName] = kept
+		}
 	}
-	st := string(src[t.Pos()-1 : t.End()-1])
-	if len(st) > 0 && st[0] == '*' {
-		st = st[1:]
+}
+
+// cgoIdent matches a reference to the cgo pseudo-package.
+var cgoIdent = regexp.MustCompile(`\bC\.`)
+
+// dropCgo removes the cgo pseudo-package from the imports of parsed, which
+// must never appear in an interface file, and reports whether it was there.
+func dropCgo(parsed *ParsedFile) bool {
+	for i, imp := range parsed.Imports {
+		if imp == `"C"` {
+			parsed.Imports = append(parsed.Imports[:i:i], parsed.Imports[i+1:]...)
+			return true
+		}
 	}
-	return st, fd
+	return false
 }
 
-func getReceiverType(fd *ast.FuncDecl) (ast.Expr, error) {
-	if fd.Recv == nil {
-		return nil, fmt.Errorf("fd is not a method, it is a function")
+// skipSource reports whether the file with the given base name is not parsed
+// with opts: skipFile, a test file without IncludeTestFiles or a file
+// matching ExcludeFiles.
+func (o Options) skipSource(name string) bool {
+	return skipFile(name) || (!o.IncludeTestFiles && isTestFile(name)) || matchAny(o.ExcludeFiles, name)
+}
+
+// skipDir reports whether the subdirectory with the given base name is not
+// walked.
+func (o Options) skipDir(name string) bool {
+	return matchAny(o.ExcludeDirs, name)
+}
+
+// skipPath reports whether path, or its trailing elements, match one of
+// SkipByFilePattern.
+func (o Options) skipPath(path string) bool {
+	for _, pattern := range o.SkipByFilePattern {
+		for sub := path; ; {
+			if ok, _ := filepath.Match(pattern, sub); ok {
+				return true
+			}
+			i := strings.IndexRune(sub, filepath.Separator)
+			if i < 0 {
+				break
+			}
+			sub = sub[i+1:]
+		}
 	}
-	return fd.Recv.List[0].Type, nil
+	return false
 }
 
-func formatFieldList(src []byte, fl *ast.FieldList) []string {
-	if fl == nil {
-		return nil
+// checkSkipPatterns rejects malformed SkipByFilePattern patterns, which
+// would otherwise never match.
+func (o Options) checkSkipPatterns() error {
+	for _, pattern := range o.SkipByFilePattern {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("struct2interface: SkipByFilePattern %q: %w", pattern, err)
+		}
 	}
-	var parts []string
-	for _, l := range fl.List {
-		names := make([]string, len(l.Names))
-		for i, n := range l.Names {
-			names[i] = n.Name
+	return nil
+}
+
+// matchAny reports whether one of patterns matches s.
+func matchAny(patterns []*regexp.Regexp, s string) bool {
+	for _, p := range patterns {
+		if p.MatchString(s) {
+			return true
 		}
-		t := string(src[l.Type.Pos()-1 : l.Type.End()-1])
+	}
+	return false
+}
 
-		if len(names) > 0 {
-			typeSharingArgs := strings.Join(names, ", ")
-			parts = append(parts, fmt.Sprintf("%s %s", typeSharingArgs, t))
-		} else {
-			parts = append(parts, t)
+// isTestFile reports whether path is a _test.go file.
+func isTestFile(path string) bool {
+	return strings.HasSuffix(path, "_test.go")
+}
+
+// testFileName returns the name of the test file generated from the test
+// sources in place of fileName, e.g. interface_pkg_test.go. It is appended
+// to names already ending in _test.go, such as those of external test
+// packages, so that they do not clash with the internal tests.
+func testFileName(fileName string) string {
+	return strings.TrimSuffix(fileName, ".go") + "_test.go"
+}
+
+// sourceGroup is a set of files of dir generated into the same files.
+type sourceGroup struct {
+	dir   string
+	files []*ParsedFile
+	test  bool
+}
+
+// splitTestFiles separates the test files among files of dir, by package
+// since external tests are in their own, from the others.
+func splitTestFiles(dir string, files []*ParsedFile) []sourceGroup {
+	var (
+		groups = []sourceGroup{{dir: dir}}
+		tests  = make(map[string]int)
+	)
+	for _, f := range files {
+		if !isTestFile(f.Path) {
+			groups[0].files = append(groups[0].files, f)
+			continue
+		}
+		i, ok := tests[f.PkgName]
+		if !ok {
+			i = len(groups)
+			tests[f.PkgName] = i
+			groups = append(groups, sourceGroup{dir: dir, test: true})
 		}
+		groups[i].files = append(groups[i].files, f)
 	}
-	return parts
+	return groups
+}
+
+// skipFile reports whether the file with the given base name is never parsed,
+// either because it is not Go source or because it is generated output.
+func skipFile(name string) bool {
+	return strings.HasPrefix(name, "interface_") ||
+		strings.HasPrefix(name, "mock_") ||
+		!strings.HasSuffix(name, ".go")
 }
 
-func parseStruct(src []byte) (pkgName string, structs []string, methods map[string][]Method, imports []string, typeDoc map[string]string, err error) {
-	fset := token.NewFileSet()
-	a, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+// ParseDirectory parses the Go files directly inside dir, without descending
+// into subdirectories, and returns the structs and methods that MakeDir would
+// generate interfaces for. Nothing is written to disk.
+func ParseDirectory(dir string, opts Options) ([]*ParsedFile, error) {
+	entries, err := os.ReadDir(dir)
 	if err != nil {
-		return
+		return nil, err
+	}
+
+	var (
+		files []*ParsedFile
+		fset  = token.NewFileSet()
+	)
+	for _, e := range entries {
+		if e.IsDir() || opts.skipSource(e.Name()) {
+			continue
+		}
+		result, err := makeFile(fset, filepath.Join(dir, e.Name()), opts)
+		if err != nil {
+			return nil, err
+		}
+		if result != nil {
+			files = append(files, result)
+		}
 	}
+	return files, nil
+}
 
-	pkgName = a.Name.Name
+// HealthCheck reports whether dir can be processed: it must be readable and
+// contain a Go file MakeDir would parse, the first such file must parse and
+// the interface file of its package must format.
+func HealthCheck(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("struct2interface: health check: %w", err)
+	}
 
-	for _, i := range a.Imports {
-		if i.Name != nil {
-			imports = append(imports, fmt.Sprintf("%s %s", i.Name.String(), i.Path.Value))
-		} else {
-			imports = append(imports, i.Path.Value)
+	for _, e := range entries {
+		if e.IsDir() || (Options{}).skipSource(e.Name()) {
+			continue
+		}
+		file := filepath.Join(dir, e.Name())
+		src, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("struct2interface: health check: %w", err)
+		}
+		if isGenerated(src) {
+			continue
 		}
+		parsed, err := parseStructFileSet(token.NewFileSet(), file, src)
+		if err != nil {
+			return fmt.Errorf("struct2interface: health check: parsing %s: %w", file, err)
+		}
+		if _, err := formatCode(strings.Join(makeInterfaceHead(parsed.PkgName, "", "", "", nil), "\n")); err != nil {
+			return fmt.Errorf("struct2interface: health check: formatting %s: %w", file, err)
+		}
+		return nil
 	}
+	return fmt.Errorf("struct2interface: health check: no Go files in %s", dir)
+}
 
-	methods = make(map[string][]Method)
-	for _, d := range a.Decls {
-		if structName, fd := getReceiverTypeName(src, d); structName != "" {
-			// 私有方法
-			if !fd.Name.IsExported() {
+// InterfaceExists reports whether one of the interface_*.go files directly
+// inside dir declares the interface of structName under its default name,
+// <structName>Interface. An interface file that does not parse is an error.
+func InterfaceExists(dir, structName string) (bool, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return false, err
+	}
+
+	iface := Options{}.interfaceName(structName)
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasPrefix(e.Name(), "interface_") || !strings.HasSuffix(e.Name(), ".go") {
+			continue
+		}
+		a, err := parser.ParseFile(token.NewFileSet(), filepath.Join(dir, e.Name()), nil, parser.SkipObjectResolution)
+		if err != nil {
+			return false, err
+		}
+		for _, d := range a.Decls {
+			gd, ok := d.(*ast.GenDecl)
+			if !ok || gd.Tok != token.TYPE {
 				continue
 			}
-			params := formatFieldList(src, fd.Type.Params)
-			ret := formatFieldList(src, fd.Type.Results)
-			method := fmt.Sprintf("%s(%s) (%s)", fd.Name.String(), strings.Join(params, ", "), strings.Join(ret, ", "))
-			var docs []string
-			if fd.Doc != nil {
-				for _, d := range fd.Doc.List {
-					docs = append(docs, string(src[d.Pos()-1:d.End()-1]))
+			for _, spec := range gd.Specs {
+				if ts := spec.(*ast.TypeSpec); ts.Name.Name == iface {
+					if _, ok := ts.Type.(*ast.InterfaceType); ok {
+						return true, nil
+					}
 				}
 			}
-			if _, ok := methods[structName]; !ok {
-				structs = append(structs, structName)
-			}
-
-			methods[structName] = append(methods[structName], Method{
-				Code: method,
-				Docs: docs,
-			})
 		}
 	}
+	return false, nil
+}
 
-	typeDoc = make(map[string]string)
-	for _, t := range doc.New(&ast.Package{Files: map[string]*ast.File{"": a}}, "", doc.AllDecls).Types {
-		typeDoc[t.Name] = strings.TrimSuffix(t.Doc, "\n")
-	}
-
-	return
+// MakeDir generates interface files for every package under dir.
+func MakeDir(dir string) error {
+	_, err := MakeDirWithOptions(dir, Options{})
+	return err
 }
 
-func formatCode(code string) ([]byte, error) {
-	opts := &imports.Options{
-		TabIndent: true,
-		TabWidth:  2,
-		Fragment:  true,
-		Comments:  true,
+// MakeDirWithOptions is like MakeDir but allows configuring the generation.
+// It returns the absolute paths of the files written, also when it fails
+// after writing some of them.
+func MakeDirWithOptions(dir string, opts Options) ([]string, error) {
+	if opts.ProfileCPU != nil {
+		if err := pprof.StartCPUProfile(opts.ProfileCPU); err != nil {
+			return nil, err
+		}
+		defer pprof.StopCPUProfile()
 	}
 
-	formatcode, err := imports.Process("", []byte(code), opts)
+	mapDirPath, err := walkDir(dir, opts)
 	if err != nil {
 		return nil, err
 	}
 
-	if string(formatcode) == code {
-		return formatcode, err
-	}
-
-	return formatCode(string(formatcode))
+	return createFile(mapDirPath, opts)
 }
 
-func makeInterfaceHead(pkgName string, imports []string) []string {
-	output := []string{
-		"// Code generated by struct2interface; DO NOT EDIT.",
-		"",
-		"package " + pkgName,
-		"import (",
-	}
-	output = append(output, imports...)
-	output = append(output,
-		")",
-		"",
-	)
-	return output
+// MakeFile generates the interfaces of the structs in the single Go file at
+// path, for use from a //go:generate directive.
+func MakeFile(path string) error {
+	return MakeFileWithOptions(path, Options{})
 }
 
-func makeInterfaceBody(output []string, ifaceComment map[string]string, structName string, methods []string) []string {
+// MakeFileWithOptions is like MakeFile but allows configuring the generation.
+// Unless opts.PathMapper is set, the output is written next to the source as
+// interface_<file>.go. Files MakeDir would skip are ignored.
+func MakeFileWithOptions(path string, opts Options) error {
+	if opts.skipSource(filepath.Base(path)) {
+		return nil
+	}
 
-	comment := strings.TrimSuffix(strings.Replace(ifaceComment[structName], "\n", "\n//\t", -1), "\n//\t")
-	if len(strings.TrimSpace(comment)) > 0 {
-		output = append(output, fmt.Sprintf("// %s", comment))
+	parsed, err := makeFile(token.NewFileSet(), path, opts)
+	if err != nil {
+		return fmt.Errorf("struct2interface: processing %s: %w", path, err)
+	}
+	if parsed == nil {
+		return nil
 	}
 
-	output = append(output, fmt.Sprintf("type %s interface {", structName+"Interface"))
-	output = append(output, methods...)
-	output = append(output, "}")
-	return output
+	if opts.PathMapper == nil {
+		opts.PathMapper = func(sourcePath, dirPath string) string {
+			return filepath.Join(dirPath, "interface_"+filepath.Base(sourcePath))
+		}
+	}
+	_, err = createFile(map[string][]*ParsedFile{filepath.Dir(path): {parsed}}, opts)
+	return err
 }
 
-func createFile(objs map[string][]*makeInterfaceFile) error {
-	for dir, obj := range objs {
-		if len(obj) == 0 {
-			continue
-		}
+// MakeBytes returns the interface file generated from src without touching
+// the filesystem, or nil if src has no struct to generate an interface for.
+// filename is only used in messages and to resolve imports. Options writing
+// several files, such as GroupBy "struct", are an error; MakeDirFS returns
+// them all.
+func MakeBytes(filename string, src []byte, opts Options) ([]byte, error) {
+	parsed, err := makeSource(token.NewFileSet(), filename, src, opts)
+	if err != nil || parsed == nil {
+		return nil, err
+	}
 
-		var (
-			startTime         = time.Now()
-			firstObj          = obj[0]
-			pkgName           = firstObj.PkgName
-			typeDoc           = firstObj.TypeDoc
-			mapStructMethods  = make(map[string][]string)
-			listStructMethods = make([]string, 0)
-			structAllImports  = make([]string, 0)
-		)
+	files, err := generateFiles(map[string][]*ParsedFile{parsed.DirPath: {parsed}}, opts)
+	if err != nil || len(files) == 0 {
+		return nil, err
+	}
+	if len(files) > 1 {
+		return nil, fmt.Errorf("struct2interface: %s generates %d files, which MakeBytes cannot return", filename, len(files))
+	}
+	return files[0].content, nil
+}
 
-		for _, file := range obj {
-			for _, structName := range file.Structs {
-				if _, ok := mapStructMethods[structName]; ok {
-					mapStructMethods[structName] = append(mapStructMethods[structName], file.AllMethods[structName]...)
-				} else {
-					mapStructMethods[structName] = file.AllMethods[structName]
-					listStructMethods = append(listStructMethods, structName)
-				}
+// MakeReader is MakeBytes reading the source from r, e.g. os.Stdin.
+func MakeReader(r io.Reader, filename string, opts Options) ([]byte, error) {
+	src, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return MakeBytes(filename, src, opts)
+}
 
-				structAllImports = append(structAllImports, file.AllImports...)
-			}
+// MakeDirFS is MakeDirWithOptions reading the Go files under dir from root,
+// e.g. an embed.FS. The generated files are returned by path instead of
+// being written.
+func MakeDirFS(root fs.FS, dir string, opts Options) (map[string][]byte, error) {
+	if err := opts.checkSkipPatterns(); err != nil {
+		return nil, err
+	}
+	var (
+		mapDirPath = make(map[string][]*ParsedFile)
+		fset       = token.NewFileSet()
+	)
+	if err := fs.WalkDir(root, dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
 		}
-
-		output := makeInterfaceHead(pkgName, structAllImports)
-
-		for _, structName := range listStructMethods {
-			methods, ok := mapStructMethods[structName]
-			if !ok {
-				continue
+		if path != dir && (opts.skipPath(filepath.FromSlash(path)) || (d.IsDir() && opts.skipDir(d.Name()))) {
+			if d.IsDir() {
+				return fs.SkipDir
 			}
-			output = makeInterfaceBody(output, typeDoc, structName, methods)
+			return nil
 		}
-
-		code := strings.Join(output, "\n")
-		result, err := formatCode(code)
+		if d.IsDir() || opts.skipSource(d.Name()) {
+			return nil
+		}
+		src, err := fs.ReadFile(root, path)
 		if err != nil {
-			fmt.Printf("[struct2interface] %s \n", "formatCode error")
 			return err
 		}
-		var fileName = filepath.Join(dir, "interface_"+pkgName+".go")
-		if err = ioutil.WriteFile(fileName, result, 0644); err != nil {
-			return err
+		parsed, err := makeSource(fset, path, src, opts)
+		if err != nil {
+			return fmt.Errorf("struct2interface: processing %s: %w", path, err)
+		}
+		if parsed != nil {
+			mapDirPath[parsed.DirPath] = append(mapDirPath[parsed.DirPath], parsed)
 		}
-		fmt.Printf("[struct2interface] %s %s %s \n", "parsing", time.Since(startTime).String(), fileName)
+		return nil
+	}); err != nil {
+		return nil, err
 	}
 
-	return nil
+	files, err := generateFiles(mapDirPath, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	contents := make(map[string][]byte, len(files))
+	for _, f := range files {
+		if contents[f.path], err = beforeWrite(f, opts); err != nil {
+			return nil, err
+		}
+	}
+	return contents, nil
 }
 
-func makeFile(file string) (*makeInterfaceFile, error) {
-	var (
-		allMethods = make(map[string][]string)
-		allImports = make([]string, 0)
-		iset       = make(map[string]struct{})
-		typeDoc    = make(map[string]string)
-	)
+// StaleFilesError is returned by CheckDir when interface files are missing
+// or differ from what would be generated.
+type StaleFilesError struct {
+	Files []string
+}
+
+func (e *StaleFilesError) Error() string {
+	return fmt.Sprintf("struct2interface: %d stale interface files: %s", len(e.Files), strings.Join(e.Files, ", "))
+}
 
-	src, err := ioutil.ReadFile(file)
+// CheckDir is ListOutdatedFiles for CI: along with the stale files it returns
+// a *StaleFilesError listing them, if any.
+func CheckDir(dir string, opts Options) ([]string, error) {
+	stale, err := ListOutdatedFiles(dir, opts)
 	if err != nil {
 		return nil, err
 	}
+	if len(stale) > 0 {
+		return stale, &StaleFilesError{Files: stale}
+	}
+	return nil, nil
+}
 
-	pkgName, structSlice, methods, importList, parsedTypeDoc, err := parseStruct(src)
+// ListOutdatedFiles returns the files MakeDirWithOptions would create or
+// change, without writing anything. With SourceHash, the packages whose
+// interface files record the hash of their sources are not generated again.
+func ListOutdatedFiles(dir string, opts Options) ([]string, error) {
+	mapDirPath, err := walkDir(dir, opts)
 	if err != nil {
-		fmt.Printf("[struct2interface] %s, err: %s\n", "file parseStruct error", err.Error())
 		return nil, err
 	}
 
-	if len(methods) == 0 {
-		return nil, nil
+	unchanged := func(path, srcHash string) bool {
+		stored, err := storedSourceHash(path)
+		return err == nil && stored == srcHash
 	}
-
-	for _, i := range importList {
-		if _, ok := iset[i]; !ok {
-			allImports = append(allImports, i)
-			iset[i] = struct{}{}
-		}
+	files, err := generateChangedFiles(mapDirPath, opts, unchanged)
+	if err != nil {
+		return nil, err
 	}
 
-	for structName, mm := range methods {
-		typeDoc[structName] = fmt.Sprintf("%s ...\n%s", structName+"Interface", parsedTypeDoc[structName])
-		for _, m := range mm {
-			allMethods[structName] = append(allMethods[structName], m.Lines()...)
+	var outdated []string
+	for _, f := range files {
+		content, err := beforeWrite(f, opts)
+		if err != nil {
+			return nil, err
+		}
+		existing, err := os.ReadFile(f.path)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		if err != nil || !bytes.Equal(existing, content) {
+			outdated = append(outdated, f.path)
 		}
 	}
-
-	return &makeInterfaceFile{
-		DirPath:    filepath.Dir(file),
-		PkgName:    pkgName,
-		Structs:    structSlice,
-		TypeDoc:    typeDoc,
-		AllMethods: allMethods,
-		AllImports: allImports,
-	}, nil
+	return outdated, nil
 }
 
-func MakeDir(dir string) error {
-	var mapDirPath = make(map[string][]*makeInterfaceFile)
+// walkDir parses every Go file under dir, grouped by directory.
+func walkDir(dir string, opts Options) (map[string][]*ParsedFile, error) {
+	if err := opts.checkSkipPatterns(); err != nil {
+		return nil, err
+	}
+	var paths []string
 	if err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
-		if d.IsDir() {
-			return nil
-		}
-		if strings.HasPrefix(filepath.Base(path), "interface_") {
-			return nil
-		}
-		if strings.HasPrefix(filepath.Base(path), "mock_") {
+		if path != dir && (opts.skipPath(path) || (d.IsDir() && opts.skipDir(d.Name()))) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
 			return nil
 		}
-		if !strings.HasSuffix(filepath.Base(path), ".go") {
+		if d.IsDir() || opts.skipSource(filepath.Base(path)) {
 			return nil
 		}
+		paths = append(paths, path)
+		return nil
+	}); err != nil {
+		opts.logger().Error("walking failed", "dir", dir, "error", err)
+		return nil, err
+	}
 
-		result, err := makeFile(path)
-		if err != nil {
-			log.Panic("struct2interface.Make failed,", err.Error(), path)
+	var (
+		mapDirPath    = make(map[string][]*ParsedFile)
+		results, errs = makeFiles(token.NewFileSet(), paths, opts)
+	)
+	for i, path := range paths {
+		result, err := results[i], errs[i]
+		if err != nil && opts.ContinueOnError {
+			if err := opts.warn("skipping file", "file", path, "error", err); err != nil {
+				return nil, fmt.Errorf("struct2interface: processing %s: %w", path, err)
+			}
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("struct2interface: processing %s: %w", path, err)
 		} else if result == nil {
-			return nil
+			continue
 		}
 
 		if _, ok := mapDirPath[filepath.Dir(path)]; ok {
 			mapDirPath[filepath.Dir(path)] = append(mapDirPath[filepath.Dir(path)], result)
 		} else {
-			mapDirPath[filepath.Dir(path)] = []*makeInterfaceFile{result}
+			mapDirPath[filepath.Dir(path)] = []*ParsedFile{result}
 		}
+	}
 
-		return nil
-	}); err != nil {
-		fmt.Printf("[struct2interface] %s \n", err.Error())
-		return err
+	return mapDirPath, nil
+}
+
+// makeFiles runs makeFile for every path on opts.workers() goroutines and
+// returns the results and errors in the order of paths. The workers share
+// fset, which is safe for concurrent use.
+func makeFiles(fset *token.FileSet, paths []string, opts Options) ([]*ParsedFile, []error) {
+	var (
+		results = make([]*ParsedFile, len(paths))
+		errs    = make([]error, len(paths))
+		jobs    = make(chan int)
+		wg      sync.WaitGroup
+	)
+	for w := 0; w < opts.workers(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i], errs[i] = makeFile(fset, paths[i], opts)
+			}
+		}()
+	}
+	for i := range paths {
+		jobs <- i
 	}
+	close(jobs)
+	wg.Wait()
 
-	return createFile(mapDirPath)
+	return results, errs
 }
@@ -1,18 +1,39 @@
 package struct2interface
 
 import (
-	"io/ioutil"
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
 	"testing"
+	"testing/fstest"
+	"testing/iotest"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
 
+// update rewrites the golden files under testdata/golden.
+var update = flag.Bool("update", false, "rewrite the golden files")
+
 const (
 	testDirCompared = `// Code generated by struct2interface; DO NOT EDIT.
 
 package case_single_file
 
 // MethodInterface ...
+//
 //	Method describes the code and documentation
 //	tied into a method
 type MethodInterface interface {
@@ -23,6 +44,7 @@ type MethodInterface interface {
 }
 
 // Method1Interface ...
+//
 //	Method1 describes the code and documentation
 //	tied into a method
 type Method1Interface interface {
@@ -47,49 +69,3583 @@ type PackageMethod2Interface interface {
 	Method1() string
 }
 `
+	testStructsCompared = `// Code generated by struct2interface; DO NOT EDIT.
+
+package testdata
+
+// PackageMethod2Interface ...
+type PackageMethod2Interface interface {
+	Method1() string
+}
+`
+	testBuildConstraintCompared = `// Code generated by struct2interface; DO NOT EDIT.
+
+//go:build linux && amd64
+
+package case_build_constraint
+
+// PlatformInterface ...
+type PlatformInterface interface {
+	Name() string
+}
+`
+	testComplianceCompared = `// Code generated by struct2interface; DO NOT EDIT.
+
+package case_compliance
+
+import (
+	"testing"
 )
 
-func TestDir(t *testing.T) {
-	err := MakeDir("./testdata/case_single_file")
-	if err != nil {
-		t.Fatal(err)
+func TestFooImplementsFooInterface(t *testing.T) {
+	var _ FooInterface = (*Foo)(nil)
+}
+
+func TestBarImplementsBarInterface(t *testing.T) {
+	var _ BarInterface = (*Bar)(nil)
+}
+`
+	testBuildTagsCompared = `// Code generated by struct2interface; DO NOT EDIT.
+
+//go:build linux && !integration
+
+package case_build_tags
+
+// PlatformInterface ...
+type PlatformInterface interface {
+	Name() string
+}
+`
+	testCommonNamesCompared = `// Code generated by struct2interface; DO NOT EDIT.
+
+package case_common_names
+
+import (
+	"go/token"
+)
+
+// NodeInterface ...
+type NodeInterface interface {
+	Pos() token.Pos
+	End() token.Pos
+	String() string
+	Error() string
+	Read(p []byte) (int, error)
+	Write(p []byte) (int, error)
+	Close() error
+	Reset()
+}
+`
+	testViewsDefaultCompared = `// Code generated by struct2interface; DO NOT EDIT.
+
+package case_views
+
+// PlainInterface ...
+type PlainInterface interface {
+	Name() string
+}
+`
+	testViewsMockCompared = `// Code generated by struct2interface; DO NOT EDIT.
+
+package mocks
+
+import (
+	"github.com/hnlq715/struct2interface/testdata/case_views"
+)
+
+// StoreInterface ...
+//
+//	Store persists users.
+type StoreInterface interface {
+	// Get returns the user with the given id.
+	Get(id int) string
+	// Find returns the user with the given name.
+	Find(name string) (*case_views.User, error)
+	// Reset drops every user.
+	Reset()
+}
+`
+	testViewsIfaceCompared = `// Code generated by struct2interface; DO NOT EDIT.
+
+package contracts
+
+import (
+	"github.com/hnlq715/struct2interface/testdata/case_views"
+)
+
+// StoreInterface ...
+//
+//	Store persists users.
+type StoreInterface interface {
+	// Get returns the user with the given id.
+	Get(id int) string
+	// Find returns the user with the given name.
+	Find(name string) (*case_views.User, error)
+}
+`
+	testSourceHashCompared = `// Code generated by struct2interface; DO NOT EDIT.
+// source-hash: sha256:d9dc584888728b953da588591f100a900c61a9db9571acadf677a035596382fa
+
+package case_source_hash
+
+// HashedInterface ...
+type HashedInterface interface {
+	Name() string
+}
+`
+	testBuilderCompared = `// Code generated by struct2interface; DO NOT EDIT.
+
+package case_builder
+
+import (
+	"io"
+)
+
+// UserInterface ...
+type UserInterface interface {
+	Age() int
+}
+
+// LevelInterface ...
+type LevelInterface interface {
+	String() string
+}
+
+// UserBuilder builds a User field by field.
+type UserBuilder struct {
+	v *User
+}
+
+// NewUserBuilder returns a builder for a zero User.
+func NewUserBuilder() *UserBuilder {
+	return &UserBuilder{v: &User{}}
+}
+
+// WithReader sets Reader.
+func (b *UserBuilder) WithReader(v io.Reader) *UserBuilder {
+	b.v.Reader = v
+	return b
+}
+
+// WithName sets Name.
+func (b *UserBuilder) WithName(v string) *UserBuilder {
+	b.v.Name = v
+	return b
+}
+
+// WithAge sets age.
+func (b *UserBuilder) WithAge(v int) *UserBuilder {
+	b.v.age = v
+	return b
+}
+
+// WithLevel sets level.
+func (b *UserBuilder) WithLevel(v int) *UserBuilder {
+	b.v.level = v
+	return b
+}
+
+// Build returns the built User as a UserInterface.
+func (b *UserBuilder) Build() UserInterface {
+	return b.v
+}
+`
+	testDispatcherCompared = `// Code generated by struct2interface; DO NOT EDIT.
+
+package case_dispatcher
+
+import "fmt"
+
+// CalcInterface ...
+type CalcInterface interface {
+	Add(a, b int) int
+	Join(sep string, parts ...string) string
+	Reset()
+}
+
+// CalcInterfaceDispatcher calls method on impl with args and returns its results.
+func CalcInterfaceDispatcher(impl CalcInterface, method string, args []interface{}) ([]interface{}, error) {
+	switch method {
+	case "Add":
+		if len(args) != 2 {
+			return nil, fmt.Errorf("%s: expected 2 arguments, got %d", method, len(args))
+		}
+		a0, ok := args[0].(int)
+		if !ok && args[0] != nil {
+			return nil, fmt.Errorf("%s: argument 0 is %T, not int", method, args[0])
+		}
+		a1, ok := args[1].(int)
+		if !ok && args[1] != nil {
+			return nil, fmt.Errorf("%s: argument 1 is %T, not int", method, args[1])
+		}
+		r0 := impl.Add(a0, a1)
+		return []interface{}{r0}, nil
+	case "Join":
+		if len(args) != 2 {
+			return nil, fmt.Errorf("%s: expected 2 arguments, got %d", method, len(args))
+		}
+		a0, ok := args[0].(string)
+		if !ok && args[0] != nil {
+			return nil, fmt.Errorf("%s: argument 0 is %T, not string", method, args[0])
+		}
+		a1, ok := args[1].([]string)
+		if !ok && args[1] != nil {
+			return nil, fmt.Errorf("%s: argument 1 is %T, not []string", method, args[1])
+		}
+		r0 := impl.Join(a0, a1...)
+		return []interface{}{r0}, nil
+	case "Reset":
+		if len(args) != 0 {
+			return nil, fmt.Errorf("%s: expected 0 arguments, got %d", method, len(args))
+		}
+		impl.Reset()
+		return nil, nil
 	}
+	return nil, fmt.Errorf("unknown method %q", method)
+}
+`
+	testDotImportCompared = `// Code generated by struct2interface; DO NOT EDIT.
 
-	output, err := ioutil.ReadFile("./testdata/case_single_file/interface_case_single_file.go")
-	if err != nil {
-		t.Fatal(err)
+package case_dot_import
+
+import (
+	. "fmt"
+)
+
+// PrinterInterface ...
+type PrinterInterface interface {
+	Wrap(s Stringer) Stringer
+}
+`
+	testFunctionalOptionsCompared = `// Code generated by struct2interface; DO NOT EDIT.
+
+package case_functional_options
+
+import (
+	"time"
+)
+
+// ConfigInterface ...
+type ConfigInterface interface {
+	WithTimeout(d time.Duration) *Config
+	WithTags(tags ...string) *Config
+	WithRetries(v int) *Config
+	Timeout() time.Duration
+}
+
+// RetriesInterface ...
+type RetriesInterface interface {
+	WithLimit(n int) Retries
+}
+
+// ConfigOption configures a Config.
+type ConfigOption func(*Config)
+
+// NewConfigWithOptions returns a new Config with opts applied in order.
+func NewConfigWithOptions(opts ...ConfigOption) *Config {
+	v := &Config{}
+	for _, opt := range opts {
+		opt(v)
 	}
+	return v
+}
 
-	if string(output) != testDirCompared {
-		t.Fail()
+// ConfigWithTimeout returns a ConfigOption calling WithTimeout.
+func ConfigWithTimeout(d time.Duration) ConfigOption {
+	return func(v *Config) {
+		v.WithTimeout(d)
 	}
 }
 
-func TestPackage(t *testing.T) {
-	err := MakeDir("./testdata/case_package")
-	if err != nil {
-		t.Fatal(err)
+// ConfigWithTags returns a ConfigOption calling WithTags.
+func ConfigWithTags(tags ...string) ConfigOption {
+	return func(v *Config) {
+		v.WithTags(tags...)
 	}
+}
 
-	output, err := ioutil.ReadFile("./testdata/case_package/interface_testdata.go")
-	if err != nil {
-		t.Fatal(err)
+// ConfigWithRetries returns a ConfigOption calling WithRetries.
+func ConfigWithRetries(p0 int) ConfigOption {
+	return func(v *Config) {
+		v.WithRetries(p0)
 	}
+}
+`
+	testCircuitBreakerCompared = `// Code generated by struct2interface; DO NOT EDIT.
 
-	if string(output) != testPackageCompared {
-		t.Fail()
+package case_circuit_breaker
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+// ClientInterface ...
+type ClientInterface interface {
+	Get(ctx context.Context, id int) (string, error)
+	Ping() error
+	Name() string
+	Reset()
+	WriteTo(w io.Writer) (int64, error)
+}
+
+// ClientCircuitBreaker wraps a ClientInterface. After maxFailures consecutive errors it
+// opens and fails fast with ErrCircuitOpen; once resetTimeout has passed a
+// single trial call decides whether it closes again.
+type ClientCircuitBreaker struct {
+	impl         ClientInterface
+	maxFailures  int
+	resetTimeout time.Duration
+
+	mu       sync.Mutex
+	state    circuitState
+	failures int
+	openedAt time.Time
+}
+
+var _ ClientInterface = (*ClientCircuitBreaker)(nil)
+
+// NewClientCircuitBreaker returns a closed circuit breaker around impl.
+func NewClientCircuitBreaker(impl ClientInterface, maxFailures int, resetTimeout time.Duration) *ClientCircuitBreaker {
+	return &ClientCircuitBreaker{impl: impl, maxFailures: maxFailures, resetTimeout: resetTimeout}
+}
+
+func (w *ClientCircuitBreaker) allow() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	switch w.state {
+	case circuitOpen:
+		if time.Since(w.openedAt) < w.resetTimeout {
+			return false
+		}
+		w.state = circuitHalfOpen
+		return true
+	case circuitHalfOpen:
+		return false
 	}
+	return true
 }
 
-func TestNil(t *testing.T) {
-	t.Run("空路径", func(t *testing.T) {
-		err := MakeDir("./notfind")
-		assert.EqualError(t, err, "lstat ./notfind: no such file or directory")
-	})
+func (w *ClientCircuitBreaker) record(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err == nil {
+		w.state, w.failures = circuitClosed, 0
+		return
+	}
+	w.failures++
+	if w.state == circuitHalfOpen || w.failures >= w.maxFailures {
+		w.state, w.openedAt = circuitOpen, time.Now()
+	}
 }
 
-func Benchmark_TestDir(b *testing.B) {
-	for i := 0; i < b.N; i++ {
-		_ = MakeDir("./testdata")
+func (w *ClientCircuitBreaker) Get(ctx context.Context, id int) (string, error) {
+	if !w.allow() {
+		var r0 string
+		return r0, ErrCircuitOpen
+	}
+	r0, r1 := w.impl.Get(ctx, id)
+	w.record(r1)
+	return r0, r1
+}
+
+func (w *ClientCircuitBreaker) Ping() error {
+	if !w.allow() {
+		return ErrCircuitOpen
+	}
+	r0 := w.impl.Ping()
+	w.record(r0)
+	return r0
+}
+
+func (w *ClientCircuitBreaker) Name() string {
+	return w.impl.Name()
+}
+
+func (w *ClientCircuitBreaker) Reset() {
+	w.impl.Reset()
+}
+
+func (w *ClientCircuitBreaker) WriteTo(p0 io.Writer) (int64, error) {
+	if !w.allow() {
+		var r0 int64
+		return r0, ErrCircuitOpen
+	}
+	r0, r1 := w.impl.WriteTo(p0)
+	w.record(r1)
+	return r0, r1
+}
+
+// ErrCircuitOpen is returned by the generated circuit breakers while they are open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+`
+	testFunctionsCompared = `// Code generated by struct2interface; DO NOT EDIT.
+
+package case_functions
+
+import (
+	"io"
+)
+
+// ClientInterface ...
+//
+// Use NewClient to construct a value satisfying this interface.
+type ClientInterface interface {
+	Close() error
+}
+
+// Case_functionsFunctions ...
+type Case_functionsFunctions interface {
+	// NewClient returns a Client.
+	NewClient() *Client
+	Parse(r io.Reader) (map[string]string, error)
+}
+`
+	testCgoCompared = `// Code generated by struct2interface; DO NOT EDIT.
+
+package case_cgo
+
+import (
+	"unsafe"
+)
+
+// BufferInterface ...
+type BufferInterface interface {
+	Ptr() unsafe.Pointer
+}
+`
+	testDeprecatedCompared = `// Code generated by struct2interface; DO NOT EDIT.
+
+package case_deprecated
+
+// StoreInterface ...
+type StoreInterface interface {
+	// Get returns the value of key.
+	Get(key string) string
+}
+`
+
+	testTestMockCompared = `// Code generated by struct2interface; DO NOT EDIT.
+
+package case_test_mock
+
+import (
+	"context"
+	"io"
+)
+
+// ClientMock is a ClientInterface calling the On<Method> field of each method.
+// Calling a method whose field is nil panics.
+type ClientMock struct {
+	OnGet     func(ctx context.Context, id int) (string, error)
+	OnLog     func(format string, args ...interface{})
+	OnWriteTo func(p0 io.Writer) (int64, error)
+}
+
+var _ ClientInterface = (*ClientMock)(nil)
+
+func (w *ClientMock) Get(ctx context.Context, id int) (string, error) {
+	if w.OnGet == nil {
+		panic("ClientMock.Get called but OnGet is nil")
+	}
+	return w.OnGet(ctx, id)
+}
+
+func (w *ClientMock) Log(format string, args ...interface{}) {
+	if w.OnLog == nil {
+		panic("ClientMock.Log called but OnLog is nil")
+	}
+	w.OnLog(format, args...)
+}
+
+func (w *ClientMock) WriteTo(p0 io.Writer) (int64, error) {
+	if w.OnWriteTo == nil {
+		panic("ClientMock.WriteTo called but OnWriteTo is nil")
+	}
+	return w.OnWriteTo(p0)
+}
+`
+
+	testAliasCompared = `// Code generated by struct2interface; DO NOT EDIT.
+
+package case_alias
+
+// DBConnectionInterface ...
+//
+//	DBConnection is a connection to the database.
+type DBConnectionInterface interface {
+	Exec(query string) error
+}
+
+// DB is an alias of DBConnectionInterface.
+type DB = DBConnectionInterface
+`
+
+	testGenericsCompared = `// Code generated by struct2interface; DO NOT EDIT.
+
+package case_generics
+
+// PairInterface ...
+//
+//	Pair holds two values.
+type PairInterface[A, B any] interface {
+	First() A
+	Second() B
+}
+
+// BoxInterface ...
+type BoxInterface[T any] interface {
+	Get() T
+}
+`
+
+	testContractsCompared = `// Code generated by struct2interface; DO NOT EDIT.
+
+package contracts
+
+import (
+	"context"
+)
+
+// StoreInterface ...
+type StoreInterface interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+}
+`
+
+	testContractsComplianceCompared = `// Code generated by struct2interface; DO NOT EDIT.
+
+package case_contracts
+
+import (
+	"github.com/hnlq715/struct2interface/testdata/case_contracts/contracts"
+)
+
+var (
+	_ contracts.StoreInterface = (*Store)(nil)
+	_ contracts.CacheInterface = (*Cache)(nil)
+)
+`
+
+	testTracerCompared = `// Code generated by struct2interface; DO NOT EDIT.
+
+package case_tracer
+
+import (
+	"context"
+	"io"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RepositoryInterface ...
+type RepositoryInterface interface {
+	Find(ctx context.Context, id int64, name string) (string, error)
+	Save(ctx context.Context, out io.Writer) error
+	Close()
+	WriteTo(ctx context.Context, w io.Writer, span int) error
+}
+
+// RepositoryTraced wraps a RepositoryInterface in an OpenTelemetry span per call.
+// Simple parameters become span attributes and returned errors are recorded
+// on the span.
+type RepositoryTraced struct {
+	impl   RepositoryInterface
+	tracer trace.Tracer
+}
+
+var _ RepositoryInterface = (*RepositoryTraced)(nil)
+
+// NewRepositoryTraced returns impl traced by tracer.
+func NewRepositoryTraced(impl RepositoryInterface, tracer trace.Tracer) *RepositoryTraced {
+	return &RepositoryTraced{impl: impl, tracer: tracer}
+}
+
+func (w *RepositoryTraced) Find(ctx context.Context, id int64, name string) (string, error) {
+	ctx, span := w.tracer.Start(ctx, "Repository.Find")
+	defer span.End()
+	span.SetAttributes(attribute.Int64("id", id), attribute.String("name", name))
+	r0, r1 := w.impl.Find(ctx, id, name)
+	if r1 != nil {
+		span.RecordError(r1)
+	}
+	return r0, r1
+}
+
+func (w *RepositoryTraced) Save(ctx context.Context, out io.Writer) error {
+	ctx, span := w.tracer.Start(ctx, "Repository.Save")
+	defer span.End()
+	r0 := w.impl.Save(ctx, out)
+	if r0 != nil {
+		span.RecordError(r0)
+	}
+	return r0
+}
+
+func (w *RepositoryTraced) Close() {
+	_, span := w.tracer.Start(context.Background(), "Repository.Close")
+	defer span.End()
+	w.impl.Close()
+}
+
+func (w *RepositoryTraced) WriteTo(ctx context.Context, p1 io.Writer, p2 int) error {
+	ctx, span := w.tracer.Start(ctx, "Repository.WriteTo")
+	defer span.End()
+	span.SetAttributes(attribute.Int("span", p2))
+	r0 := w.impl.WriteTo(ctx, p1, p2)
+	if r0 != nil {
+		span.RecordError(r0)
+	}
+	return r0
+}
+`
+
+	testGettersCompared = `// Code generated by struct2interface; DO NOT EDIT.
+
+package case_getters
+
+// SvcInterface ...
+type SvcInterface interface {
+	GetName() string
+	Name() string
+	SetName(name string)
+}
+`
+
+	testMockRegistryCompared = `// Code generated by struct2interface; DO NOT EDIT.
+
+package case_test_mock
+
+import (
+	"reflect"
+)
+
+// MockRegistry maps the name of every generated interface to the type of its mock.
+var MockRegistry = make(map[string]reflect.Type)
+
+func init() {
+	MockRegistry["ClientInterface"] = reflect.TypeOf((*ClientMock)(nil))
+}
+`
+
+	testEmbedCompared = `// Code generated by struct2interface; DO NOT EDIT.
+
+package case_embed
+
+// BaseInterface ...
+type BaseInterface interface {
+	ID() string
+}
+
+// NamedInterface ...
+type NamedInterface interface {
+	Name() string
+}
+
+// UserInterface ...
+type UserInterface interface {
+	BaseInterface
+	NamedInterface
+	Email() string
+}
+`
+
+	testRetryCompared = `// Code generated by struct2interface; DO NOT EDIT.
+
+package case_retry
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// ClientInterface ...
+type ClientInterface interface {
+	Fetch(ctx context.Context, url string) ([]byte, error)
+	Ping() error
+	Name() string
+	WriteTo(ctx context.Context, w io.Writer, attempt int) error
+}
+
+// ClientWithRetry wraps a ClientInterface. Methods returning an error are retried up
+// to MaxRetries times, waiting BaseDelay before the first retry and
+// Multiplier times longer before every following one.
+type ClientWithRetry struct {
+	impl ClientInterface
+
+	MaxRetries int
+	BaseDelay  time.Duration
+	Multiplier float64
+}
+
+var _ ClientInterface = (*ClientWithRetry)(nil)
+
+// NewClientWithRetry returns impl retried with the given backoff.
+func NewClientWithRetry(impl ClientInterface, maxRetries int, baseDelay time.Duration, multiplier float64) *ClientWithRetry {
+	return &ClientWithRetry{impl: impl, MaxRetries: maxRetries, BaseDelay: baseDelay, Multiplier: multiplier}
+}
+
+func (w *ClientWithRetry) backoff(attempt int) time.Duration {
+	delay := float64(w.BaseDelay)
+	for i := 0; i < attempt; i++ {
+		delay *= w.Multiplier
+	}
+	return time.Duration(delay)
+}
+
+func (w *ClientWithRetry) Fetch(ctx context.Context, url string) ([]byte, error) {
+	for attempt := 0; ; attempt++ {
+		r0, r1 := w.impl.Fetch(ctx, url)
+		if r1 == nil || attempt >= w.MaxRetries {
+			return r0, r1
+		}
+		time.Sleep(w.backoff(attempt))
+	}
+}
+
+func (w *ClientWithRetry) Ping() error {
+	for attempt := 0; ; attempt++ {
+		r0 := w.impl.Ping()
+		if r0 == nil || attempt >= w.MaxRetries {
+			return r0
+		}
+		time.Sleep(w.backoff(attempt))
+	}
+}
+
+func (w *ClientWithRetry) Name() string {
+	return w.impl.Name()
+}
+
+func (w *ClientWithRetry) WriteTo(ctx context.Context, p1 io.Writer, p2 int) error {
+	for attempt := 0; ; attempt++ {
+		r0 := w.impl.WriteTo(ctx, p1, p2)
+		if r0 == nil || attempt >= w.MaxRetries {
+			return r0
+		}
+		time.Sleep(w.backoff(attempt))
+	}
+}
+`
+
+	testCacheCompared = `// Code generated by struct2interface; DO NOT EDIT.
+
+package case_cache
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// StoreInterface ...
+type StoreInterface interface {
+	GetUser(ctx context.Context, id int) (*User, error)
+	ListNames(prefix string, limit int) []string
+	Save(ctx context.Context, u *User) error
+	Reset()
+	GetEntry(ctx context.Context, w io.Writer, key string) (string, error)
+}
+
+// StoreCache wraps a StoreInterface, memoizing the results of its read methods
+// until any other method is called.
+type StoreCache struct {
+	impl  StoreInterface
+	cache sync.Map
+}
+
+var _ StoreInterface = (*StoreCache)(nil)
+
+// NewStoreCache returns impl with its read methods memoized.
+func NewStoreCache(impl StoreInterface) *StoreCache {
+	return &StoreCache{impl: impl}
+}
+
+func (w *StoreCache) invalidate() {
+	w.cache.Range(func(key, _ interface{}) bool {
+		w.cache.Delete(key)
+		return true
+	})
+}
+
+func (w *StoreCache) GetUser(ctx context.Context, id int) (*User, error) {
+	key := fmt.Sprintf("GetUser%#v", []interface{}{id})
+	if v, ok := w.cache.Load(key); ok {
+		rs := v.([]interface{})
+		r0, _ := rs[0].(*User)
+		r1, _ := rs[1].(error)
+		return r0, r1
+	}
+	r0, r1 := w.impl.GetUser(ctx, id)
+	if r1 == nil {
+		w.cache.Store(key, []interface{}{r0, r1})
+	}
+	return r0, r1
+}
+
+func (w *StoreCache) ListNames(prefix string, limit int) []string {
+	key := fmt.Sprintf("ListNames%#v", []interface{}{prefix, limit})
+	if v, ok := w.cache.Load(key); ok {
+		rs := v.([]interface{})
+		r0, _ := rs[0].([]string)
+		return r0
+	}
+	r0 := w.impl.ListNames(prefix, limit)
+	w.cache.Store(key, []interface{}{r0})
+	return r0
+}
+
+func (w *StoreCache) Save(ctx context.Context, u *User) error {
+	r0 := w.impl.Save(ctx, u)
+	w.invalidate()
+	return r0
+}
+
+func (w *StoreCache) Reset() {
+	w.impl.Reset()
+	w.invalidate()
+}
+
+func (w *StoreCache) GetEntry(ctx context.Context, p1 io.Writer, p2 string) (string, error) {
+	key := fmt.Sprintf("GetEntry%#v", []interface{}{p1, p2})
+	if v, ok := w.cache.Load(key); ok {
+		rs := v.([]interface{})
+		r0, _ := rs[0].(string)
+		r1, _ := rs[1].(error)
+		return r0, r1
+	}
+	r0, r1 := w.impl.GetEntry(ctx, p1, p2)
+	if r1 == nil {
+		w.cache.Store(key, []interface{}{r0, r1})
+	}
+	return r0, r1
+}
+`
+
+	testOutputDirCompared = `// Code generated by struct2interface; DO NOT EDIT.
+
+package gen
+
+import (
+	"context"
+
+	"github.com/hnlq715/struct2interface/testdata/case_output_dir"
+)
+
+// StoreInterface ...
+type StoreInterface interface {
+	Get(ctx context.Context, id int) (*case_output_dir.User, error)
+	Put(users ...case_output_dir.User) error
+	User(name string) case_output_dir.User
+}
+`
+
+	testOutputDirMultiFileCompared = `// Code generated by struct2interface; DO NOT EDIT.
+
+package gen
+
+import (
+	"github.com/hnlq715/struct2interface/testdata/case_output_dir_multi"
+)
+
+// ServiceInterface ...
+type ServiceInterface interface {
+	Get(id int) (*case_output_dir_multi.User, error)
+}
+`
+
+	testTimeoutCompared = `// Code generated by struct2interface; DO NOT EDIT.
+
+package case_timeout
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// ClientInterface ...
+type ClientInterface interface {
+	Fetch(ctx context.Context, url string) ([]byte, error)
+	Ping(_ context.Context) error
+	Notify(ctx context.Context, msg string)
+	Name() string
+	Send(ctx context.Context, w io.Writer, cancel bool) error
+}
+
+// ClientWithTimeout wraps a ClientInterface, bounding the context of its methods with a
+// timeout.
+type ClientWithTimeout struct {
+	impl ClientInterface
+}
+
+var _ ClientInterface = (*ClientWithTimeout)(nil)
+
+// NewClientWithTimeout returns impl with its method timeouts.
+func NewClientWithTimeout(impl ClientInterface) *ClientWithTimeout {
+	return &ClientWithTimeout{impl: impl}
+}
+
+func (w *ClientWithTimeout) Fetch(ctx context.Context, url string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	return w.impl.Fetch(ctx, url)
+}
+
+func (w *ClientWithTimeout) Ping(p0 context.Context) error {
+	p0, cancel := context.WithTimeout(p0, 250*time.Millisecond)
+	defer cancel()
+	return w.impl.Ping(p0)
+}
+
+func (w *ClientWithTimeout) Notify(ctx context.Context, msg string) {
+	w.impl.Notify(ctx, msg)
+}
+
+func (w *ClientWithTimeout) Name() string {
+	return w.impl.Name()
+}
+
+func (w *ClientWithTimeout) Send(ctx context.Context, p1 io.Writer, p2 bool) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	return w.impl.Send(ctx, p1, p2)
+}
+`
+
+	testChangeDetectorCompared = `// Code generated by struct2interface; DO NOT EDIT.
+
+package case_change_detector
+
+import (
+	"context"
+	"io"
+	"reflect"
+	"sync"
+)
+
+// ConfigInterface ...
+type ConfigInterface interface {
+	Version() int
+	Lookup(ctx context.Context, key string) (string, bool, error)
+	Reload() error
+	WriteTo(w io.Writer) (int64, error)
+}
+
+// ConfigChangeDetector wraps a ConfigInterface, calling OnChange when a method returns
+// values different from those of its previous successful call.
+type ConfigChangeDetector struct {
+	impl ConfigInterface
+	// OnChange is called with the method name and its previous and new
+	// values, in a []interface{} for methods returning several.
+	OnChange func(methodName string, oldVal, newVal interface{})
+	mu       sync.Mutex
+	last     map[string]interface{}
+}
+
+var _ ConfigInterface = (*ConfigChangeDetector)(nil)
+
+// NewConfigChangeDetector returns impl reporting the changes of its values to
+// onChange.
+func NewConfigChangeDetector(impl ConfigInterface, onChange func(methodName string, oldVal, newVal interface{})) *ConfigChangeDetector {
+	return &ConfigChangeDetector{impl: impl, OnChange: onChange, last: make(map[string]interface{})}
+}
+
+// observe records newVal as the last value of methodName, calling
+// OnChange unless it is the first one or equal to the previous one.
+func (w *ConfigChangeDetector) observe(methodName string, newVal interface{}) {
+	w.mu.Lock()
+	oldVal, seen := w.last[methodName]
+	w.last[methodName] = newVal
+	w.mu.Unlock()
+	if seen && w.OnChange != nil && !reflect.DeepEqual(oldVal, newVal) {
+		w.OnChange(methodName, oldVal, newVal)
+	}
+}
+
+func (w *ConfigChangeDetector) Version() int {
+	r0 := w.impl.Version()
+	w.observe("Version", r0)
+	return r0
+}
+
+func (w *ConfigChangeDetector) Lookup(ctx context.Context, key string) (string, bool, error) {
+	r0, r1, r2 := w.impl.Lookup(ctx, key)
+	if r2 == nil {
+		w.observe("Lookup", []interface{}{r0, r1})
+	}
+	return r0, r1, r2
+}
+
+func (w *ConfigChangeDetector) Reload() error {
+	return w.impl.Reload()
+}
+
+func (w *ConfigChangeDetector) WriteTo(p0 io.Writer) (int64, error) {
+	r0, r1 := w.impl.WriteTo(p0)
+	if r1 == nil {
+		w.observe("WriteTo", r0)
+	}
+	return r0, r1
+}
+`
+)
+
+func TestDir(t *testing.T) {
+	err := MakeDir("./testdata/case_single_file")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := os.ReadFile("./testdata/case_single_file/interface_case_single_file.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(output) != testDirCompared {
+		t.Fail()
+	}
+}
+
+func TestPackage(t *testing.T) {
+	err := MakeDir("./testdata/case_package")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := os.ReadFile("./testdata/case_package/interface_testdata.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(output) != testPackageCompared {
+		t.Fail()
+	}
+}
+
+func TestStructs(t *testing.T) {
+	_, err := MakeDirWithOptions("./testdata/case_structs", Options{Structs: []string{"PackageMethod2"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := os.ReadFile("./testdata/case_structs/interface_testdata.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, testStructsCompared, string(output))
+}
+
+func TestBuildConstraint(t *testing.T) {
+	err := MakeDir("./testdata/case_build_constraint")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := os.ReadFile("./testdata/case_build_constraint/interface_case_build_constraint.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, testBuildConstraintCompared, string(output))
+}
+
+func TestJoinBuildConstraints(t *testing.T) {
+	c, err := joinBuildConstraints([]string{"linux", "", "linux", "amd64 || arm64"})
+	assert.NoError(t, err)
+	assert.Equal(t, "linux && (amd64 || arm64)", c)
+}
+
+func TestParenReceiver(t *testing.T) {
+	src := []byte(`package svc
+
+type Svc struct{}
+
+func ((*Svc)) Foo() {}
+
+func (s *(Svc)) Bar() {}
+
+func ((Svc)) Baz() {}
+`)
+	parsed, err := parseStruct(src)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Svc"}, parsed.Structs)
+	assert.Len(t, parsed.Methods["Svc"], 3)
+}
+
+func TestResults(t *testing.T) {
+	src := []byte(`package svc
+
+type Svc struct{}
+
+func (s *Svc) Zero(a int) {}
+
+func (s *Svc) Single() error { return nil }
+
+func (s *Svc) SingleNamed() (err error) { return nil }
+
+func (s *Svc) Multiple() (int, error) { return 0, nil }
+
+func (s *Svc) MultipleNamed() (n int, err error) { return 0, nil }
+`)
+	parsed, err := parseStruct(src)
+	assert.NoError(t, err)
+
+	var codes []string
+	for _, m := range parsed.Methods["Svc"] {
+		codes = append(codes, m.Code)
+	}
+	assert.Equal(t, []string{
+		"Zero(a int)",
+		"Single() error",
+		"SingleNamed() (err error)",
+		"Multiple() (int, error)",
+		"MultipleNamed() (n int, err error)",
+	}, codes)
+}
+
+func TestParseDirectory(t *testing.T) {
+	files, err := ParseDirectory("./testdata/case_package", Options{Structs: []string{"PackageMethod"}})
+	assert.NoError(t, err)
+	if assert.Len(t, files, 2) {
+		assert.Equal(t, "testdata/case_package/testpackagedata.go", files[0].Path)
+		assert.Equal(t, []string{"PackageMethod"}, files[0].Structs)
+		assert.Equal(t, "Method1() string", files[0].Methods["PackageMethod"][0].Code)
+		assert.Equal(t, "Method2() string", files[1].Methods["PackageMethod"][0].Code)
+	}
+
+	_, err = ParseDirectory("./notfind", Options{})
+	assert.Error(t, err)
+}
+
+func TestComplianceTest(t *testing.T) {
+	_, err := MakeDirWithOptions("./testdata/case_compliance", Options{GenComplianceTest: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := os.ReadFile("./testdata/case_compliance/interface_compliance_test.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, testComplianceCompared, string(output))
+}
+
+func TestBuildTags(t *testing.T) {
+	_, err := MakeDirWithOptions("./testdata/case_build_tags", Options{BuildTags: []string{"!integration"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := os.ReadFile("./testdata/case_build_tags/interface_case_build_tags.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, testBuildTagsCompared, string(output))
+}
+
+func TestCommonMethodNames(t *testing.T) {
+	err := MakeDir("./testdata/case_common_names")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := os.ReadFile("./testdata/case_common_names/interface_case_common_names.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, testCommonNamesCompared, string(output))
+}
+
+func TestPathMapper(t *testing.T) {
+	var (
+		out     = filepath.Join(t.TempDir(), "package_iface.go")
+		gotSrc  string
+		gotDir  string
+		mapPath = func(sourcePath, dirPath string) string {
+			gotSrc, gotDir = sourcePath, dirPath
+			return out
+		}
+	)
+	_, err := MakeDirWithOptions("./testdata/case_package", Options{PathMapper: mapPath})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "testdata/case_package/testpackagedata.go", gotSrc)
+	assert.Equal(t, "testdata/case_package", gotDir)
+
+	output, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, testPackageCompared, string(output))
+}
+
+func TestOutputViews(t *testing.T) {
+	err := MakeDir("./testdata/case_views")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for fileName, compared := range map[string]string{
+		"./testdata/case_views/interface_case_views.go":          testViewsDefaultCompared,
+		"./testdata/case_views/mocks/interface_mocks.go":         testViewsMockCompared,
+		"./testdata/case_views/contracts/interface_contracts.go": testViewsIfaceCompared,
+	} {
+		output, err := os.ReadFile(fileName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, compared, string(output), fileName)
+	}
+}
+
+func TestSourceHash(t *testing.T) {
+	_, err := MakeDirWithOptions("./testdata/case_source_hash", Options{SourceHash: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := os.ReadFile("./testdata/case_source_hash/interface_case_source_hash.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, testSourceHashCompared, string(output))
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "svc.go")
+	if err := os.WriteFile(src, []byte("package svc\n\ntype Svc struct{}\n\nfunc (s *Svc) Get() {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	written, err := MakeDirWithOptions(dir, Options{SourceHash: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the matching hash skips the generation, even of an edited file
+	output, err = os.ReadFile(written[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(written[0], bytes.Replace(output, []byte("Get()"), []byte("Put()"), 1), 0644); err != nil {
+		t.Fatal(err)
+	}
+	outdated, err := ListOutdatedFiles(dir, Options{SourceHash: true})
+	assert.NoError(t, err)
+	assert.Empty(t, outdated)
+	outdated, err = ListOutdatedFiles(dir, Options{})
+	assert.NoError(t, err)
+	assert.Len(t, outdated, 1)
+
+	if err := os.WriteFile(src, []byte("package svc\n\ntype Svc struct{}\n\nfunc (s *Svc) Get() int { return 0 }\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err = CheckDir(dir, Options{SourceHash: true})
+	var stale *StaleFilesError
+	if assert.ErrorAs(t, err, &stale) {
+		assert.Equal(t, written, stale.Files)
+	}
+}
+
+func TestMkdirAll(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "gen", "iface", "interface.go")
+	mapPath := func(sourcePath, dirPath string) string { return out }
+
+	_, err := MakeDirWithOptions("./testdata/case_package", Options{PathMapper: mapPath, DisableMkdirAll: true})
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+
+	_, err = MakeDirWithOptions("./testdata/case_package", Options{PathMapper: mapPath})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, testPackageCompared, string(output))
+}
+
+func TestBuilder(t *testing.T) {
+	_, err := MakeDirWithOptions("./testdata/case_builder", Options{GenBuilder: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := os.ReadFile("./testdata/case_builder/interface_case_builder.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, testBuilderCompared, string(output))
+}
+
+func TestPreProcess(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "interface.go")
+	mapPath := func(sourcePath, dirPath string) string { return out }
+	rename := func(src []byte) ([]byte, error) {
+		return bytes.ReplaceAll(src, []byte("Method2()"), []byte("Renamed()")), nil
+	}
+
+	_, err := MakeDirWithOptions("./testdata/case_package", Options{PathMapper: mapPath, PreProcess: rename})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, strings.Replace(testPackageCompared, "Method2()", "Renamed()", 1), string(output))
+
+	t.Run("error", func(t *testing.T) {
+		_, err := makeFile(token.NewFileSet(), "./testdata/case_package/testpackagedata.go", Options{
+			PreProcess: func(src []byte) ([]byte, error) { return nil, errors.New("boom") },
+		})
+		assert.EqualError(t, err, "boom")
+	})
+}
+
+func TestPostProcess(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "interface.go")
+	mapPath := func(sourcePath, dirPath string) string { return out }
+	copyright := func(generated []byte) ([]byte, error) {
+		return append([]byte("// Copyright 2023 The Authors.\n\n"), generated...), nil
+	}
+
+	_, err := MakeDirWithOptions("./testdata/case_package", Options{PathMapper: mapPath, PostProcess: copyright})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "// Copyright 2023 The Authors.\n\n"+testPackageCompared, string(output))
+
+	_, err = MakeDirWithOptions("./testdata/case_package", Options{
+		PathMapper:  mapPath,
+		PostProcess: func(generated []byte) ([]byte, error) { return nil, errors.New("boom") },
+	})
+	assert.EqualError(t, err, "boom")
+}
+
+func TestListOutdatedFiles(t *testing.T) {
+	err := MakeDir("./testdata/case_package")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outdated, err := ListOutdatedFiles("./testdata/case_package", Options{})
+	assert.NoError(t, err)
+	assert.Empty(t, outdated)
+
+	outdated, err = ListOutdatedFiles("./testdata/case_package", Options{Structs: []string{"PackageMethod"}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"testdata/case_package/interface_testdata.go"}, outdated)
+
+	missing := func(sourcePath, dirPath string) string { return filepath.Join(t.TempDir(), "missing.go") }
+	outdated, err = ListOutdatedFiles("./testdata/case_package", Options{PathMapper: missing})
+	assert.NoError(t, err)
+	assert.Len(t, outdated, 1)
+}
+
+func TestCheckDir(t *testing.T) {
+	err := MakeDir("./testdata/case_package")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stale, err := CheckDir("./testdata/case_package", Options{})
+	assert.NoError(t, err)
+	assert.Empty(t, stale)
+
+	stale, err = CheckDir("./testdata/case_package", Options{Structs: []string{"PackageMethod"}})
+	assert.Equal(t, []string{"testdata/case_package/interface_testdata.go"}, stale)
+	var staleErr *StaleFilesError
+	if assert.True(t, errors.As(err, &staleErr)) {
+		assert.Equal(t, stale, staleErr.Files)
+	}
+	assert.EqualError(t, err, "struct2interface: 1 stale interface files: testdata/case_package/interface_testdata.go")
+}
+
+func TestDispatcher(t *testing.T) {
+	_, err := MakeDirWithOptions("./testdata/case_dispatcher", Options{GenDispatcher: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := os.ReadFile("./testdata/case_dispatcher/interface_case_dispatcher.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, testDispatcherCompared, string(output))
+}
+
+func TestDotImport(t *testing.T) {
+	err := MakeDir("./testdata/case_dot_import")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := os.ReadFile("./testdata/case_dot_import/interface_case_dot_import.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, testDotImportCompared, string(output))
+
+	_, err = makeFile(token.NewFileSet(), "./testdata/case_dot_import/testdata.go", Options{ForbidDotImports: true})
+	assert.EqualError(t, err, `./testdata/case_dot_import/testdata.go: dot import "fmt" is forbidden`)
+}
+
+func TestMethodGrouping(t *testing.T) {
+	var methods []Method
+	for _, name := range []string{"SetName", "GetName", "Close", "GetAge", "SetAge", "HTTPGet"} {
+		methods = append(methods, Method{Name: name, Code: name + "()"})
+	}
+
+	assert.Equal(t, []string{"SetName()", "GetName()", "Close()", "GetAge()", "SetAge()", "HTTPGet()"}, groupMethodLines(methods, "none"))
+	assert.Equal(t, []string{"Close()", "GetAge()", "GetName()", "HTTPGet()", "SetAge()", "SetName()"}, groupMethodLines(methods, "alphabetical"))
+	assert.Equal(t, []string{
+		"// Setters",
+		"SetName()",
+		"SetAge()",
+		"",
+		"// Getters",
+		"GetName()",
+		"GetAge()",
+		"",
+		"// Other methods",
+		"Close()",
+		"HTTPGet()",
+	}, groupMethodLines(methods, "prefix"))
+
+	_, err := MakeDirWithOptions("./testdata/case_package", Options{MethodGrouping: "random"})
+	assert.EqualError(t, err, `struct2interface: unknown MethodGrouping "random"`)
+}
+
+func TestFunctionalOptions(t *testing.T) {
+	_, err := MakeDirWithOptions("./testdata/case_functional_options", Options{GenFunctionalOptions: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := os.ReadFile("./testdata/case_functional_options/interface_case_functional_options.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, testFunctionalOptionsCompared, string(output))
+}
+
+func TestFileProcessors(t *testing.T) {
+	var (
+		out     = filepath.Join(t.TempDir(), "interface.go")
+		mapPath = func(sourcePath, dirPath string) string { return out }
+		paths   []string
+		replace = func(old, new string) FileProcessor {
+			return FileProcessorFunc(func(path string, src []byte) ([]byte, error) {
+				paths = append(paths, path)
+				return bytes.ReplaceAll(src, []byte(old), []byte(new)), nil
+			})
+		}
+	)
+
+	_, err := MakeDirWithOptions("./testdata/case_package", Options{
+		Workers:        1,
+		PathMapper:     mapPath,
+		FileProcessors: []FileProcessor{replace("Method2()", "Renamed()"), replace("Renamed()", "Twice()")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{
+		"testdata/case_package/testpackagedata.go",
+		"testdata/case_package/testpackagedata.go",
+		"testdata/case_package/testpackagedata1.go",
+		"testdata/case_package/testpackagedata1.go",
+	}, paths)
+
+	output, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, strings.Replace(testPackageCompared, "Method2()", "Twice()", 1), string(output))
+}
+
+func TestBeforeWrite(t *testing.T) {
+	var (
+		out     = filepath.Join(t.TempDir(), "interface.go")
+		mapPath = func(sourcePath, dirPath string) string { return out }
+		hook    = func(path string, content []byte) ([]byte, error) {
+			if filepath.Base(path) == "interface_compliance_test.go" {
+				return nil, errors.New("skipped")
+			}
+			return bytes.ReplaceAll(content, []byte("DO NOT EDIT."), []byte("DO NOT EDIT!")), nil
+		}
+	)
+
+	_, err := MakeDirWithOptions("./testdata/case_compliance", Options{PathMapper: mapPath, GenComplianceTest: true, BeforeWrite: hook})
+	assert.EqualError(t, err, "testdata/case_compliance/interface_compliance_test.go: skipped")
+
+	output, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, string(output), "// Code generated by struct2interface; DO NOT EDIT!")
+
+	output, err = os.ReadFile("./testdata/case_compliance/interface_compliance_test.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, testComplianceCompared, string(output))
+}
+
+func TestErrorOnMissingImport(t *testing.T) {
+	_, err := MakeDirWithOptions("./testdata/case_missing_import", Options{ErrorOnMissingImport: true})
+	assert.EqualError(t, err, "testdata/case_missing_import/interface_case_missing_import.go: cannot resolve imports example.com/missing/pkg")
+
+	out := filepath.Join(t.TempDir(), "interface.go")
+	_, err = MakeDirWithOptions("./testdata/case_common_names", Options{
+		ErrorOnMissingImport: true,
+		PathMapper:           func(sourcePath, dirPath string) string { return out },
+	})
+	assert.NoError(t, err)
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	_, err := MakeDirWithOptions("./testdata/case_circuit_breaker", Options{GenCircuitBreaker: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := os.ReadFile("./testdata/case_circuit_breaker/interface_case_circuit_breaker.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, testCircuitBreakerCompared, string(output))
+}
+
+func TestIncludeFunctions(t *testing.T) {
+	_, err := MakeDirWithOptions("./testdata/case_functions", Options{IncludeFunctions: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := os.ReadFile("./testdata/case_functions/interface_case_functions.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, testFunctionsCompared, string(output))
+
+	files, err := ParseDirectory("./testdata/case_functions", Options{IncludeFunctions: true, Structs: []string{"case_functions"}})
+	assert.NoError(t, err)
+	if assert.Len(t, files, 1) {
+		assert.Empty(t, files[0].Structs)
+		assert.Len(t, files[0].Functions, 2)
+	}
+
+	opts := Options{IncludeFunctions: true, FunctionsInterfaceName: "API"}
+	assert.Equal(t, "API", opts.functionsInterfaceName("case_functions"))
+}
+
+func TestCgo(t *testing.T) {
+	_, err := MakeDirWithOptions("./testdata/case_cgo", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := os.ReadFile("./testdata/case_cgo/interface_case_cgo.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, testCgoCompared, string(output))
+}
+
+func TestSkipDeprecated(t *testing.T) {
+	_, err := MakeDirWithOptions("./testdata/case_deprecated", Options{SkipDeprecated: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := os.ReadFile("./testdata/case_deprecated/interface_case_deprecated.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, testDeprecatedCompared, string(output))
+}
+
+func TestCheckImports(t *testing.T) {
+	for path, name := range map[string]string{
+		"context":                     "context",
+		"github.com/pkg/route":        "route",
+		"github.com/pkg/route/v2":     "route",
+		"gopkg.in/yaml.v3":            "yaml",
+		"github.com/mattn/go-sqlite3": "sqlite3",
+	} {
+		assert.Equal(t, name, assumedPackageName(path), path)
+	}
+
+	methods := map[string][]Method{
+		"Svc": {
+			{Name: "Get", Code: "Get(ctx context.Context, r *route.Route) (*yaml.Node, error)"},
+			{Name: "Put", Code: "Put(req *route.Request) http.Header"},
+		},
+	}
+	err := checkImports([]string{`"context"`, `r "github.com/pkg/route"`, `"gopkg.in/yaml.v3"`}, methods)
+	assert.EqualError(t, err, "struct2interface: missing imports for route.Route in Svc.Get, route.Request in Svc.Put, http.Header in Svc.Put")
+
+	var missing *MissingImportError
+	if assert.True(t, errors.As(err, &missing)) {
+		assert.Equal(t, MissingImport{Struct: "Svc", Method: "Put", Type: "http.Header"}, missing.Missing[2])
+	}
+
+	assert.NoError(t, checkImports([]string{`"context"`, `"github.com/pkg/route"`, `"gopkg.in/yaml.v3"`, `"net/http"`}, methods))
+}
+
+func TestWorkers(t *testing.T) {
+	sequential, err := walkDir("./testdata", Options{Workers: 1})
+	assert.NoError(t, err)
+
+	parallel, err := walkDir("./testdata", Options{Workers: 4})
+	assert.NoError(t, err)
+	assert.Equal(t, sequential, parallel)
+
+	assert.Equal(t, runtime.NumCPU(), Options{}.workers())
+	assert.Equal(t, 1, Options{Workers: 1}.workers())
+}
+
+func TestGenTestMock(t *testing.T) {
+	_, err := MakeDirWithOptions("./testdata/case_test_mock", Options{GenTestMock: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := os.ReadFile("./testdata/case_test_mock/interface_case_test_mock_mock_test.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, testTestMockCompared, string(output))
+}
+
+func TestAlias(t *testing.T) {
+	_, err := MakeDirWithOptions("./testdata/case_alias", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := os.ReadFile("./testdata/case_alias/interface_case_alias.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, testAliasCompared, string(output))
+}
+
+func TestStructDocSuffix(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "interface.go")
+	mapPath := func(sourcePath, dirPath string) string { return out }
+
+	_, err := MakeDirWithOptions("./testdata/case_alias", Options{PathMapper: mapPath, StructDocSuffix: " defines the contract for\n"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := strings.Replace(testAliasCompared, "DBConnectionInterface ...\n", "DBConnectionInterface defines the contract for\n", 1)
+	assert.Equal(t, expected, string(output))
+}
+
+func TestGenericReceivers(t *testing.T) {
+	_, err := MakeDirWithOptions("./testdata/case_generics", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := os.ReadFile("./testdata/case_generics/interface_case_generics.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, testGenericsCompared, string(output))
+}
+
+func TestOnDuplicateMethod(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "interface.go")
+	mapPath := func(sourcePath, dirPath string) string { return out }
+
+	// both files declare Path, only one of them for the current platform
+	all := build.Default
+	all.UseAllFiles = true
+
+	var duplicates []string
+	_, err := MakeDirWithOptions("./testdata/case_duplicate", Options{
+		PathMapper:   mapPath,
+		BuildContext: &all,
+		OnDuplicateMethod: func(structName, methodName, file1, file2 string) error {
+			duplicates = append(duplicates, structName+"."+methodName+" "+filepath.Base(file1)+" "+filepath.Base(file2))
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{"Store.Path store_unix.go store_windows.go"}, duplicates)
+
+	output, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, strings.Count(string(output), "Path() string"))
+
+	_, err = MakeDirWithOptions("./testdata/case_duplicate", Options{
+		PathMapper:   mapPath,
+		BuildContext: &all,
+		OnDuplicateMethod: func(structName, methodName, file1, file2 string) error {
+			return errors.New("duplicate " + methodName)
+		},
+	})
+	assert.EqualError(t, err, "duplicate Path")
+}
+
+func TestContractsPackage(t *testing.T) {
+	written, err := MakeDirWithOptions("./testdata/case_contracts", Options{GenContractsPackage: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := os.ReadFile("./testdata/case_contracts/contracts/StoreInterface.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, testContractsCompared, string(output))
+
+	output, err = os.ReadFile("./testdata/case_contracts/compliance.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, testContractsComplianceCompared, string(output))
+
+	unwanted, err := filepath.Abs("./testdata/case_contracts/interface_case_contracts.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotContains(t, written, unwanted)
+	assert.Len(t, written, 3)
+}
+
+func TestPackageComment(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "interface.go")
+	mapPath := func(sourcePath, dirPath string) string { return out }
+
+	_, err := MakeDirWithOptions("./testdata/case_alias", Options{PathMapper: mapPath, PackageComment: "Package case_alias holds the database contracts.\n\nIt is generated."})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := strings.Replace(testAliasCompared, "\npackage case_alias\n", "\n// Package case_alias holds the database contracts.\n//\n// It is generated.\npackage case_alias\n", 1)
+	assert.Equal(t, expected, string(output))
+}
+
+func TestFastFormat(t *testing.T) {
+	_, err := MakeDirWithOptions("./testdata/case_contracts", Options{GenContractsPackage: true, FastFormat: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := os.ReadFile("./testdata/case_contracts/contracts/StoreInterface.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, testContractsCompared, string(output))
+
+	output, err = os.ReadFile("./testdata/case_contracts/compliance.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, testContractsComplianceCompared, string(output))
+
+	_, err = MakeDirWithOptions("./testdata/case_alias", Options{FastFormat: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output, err = os.ReadFile("./testdata/case_alias/interface_case_alias.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, testAliasCompared, string(output))
+
+	assert.True(t, canFastFormat([]byte("package p\n\nimport \"context\"\n\ntype I interface{ Do(context.Context) }\n")))
+	assert.False(t, canFastFormat([]byte("package p\n\nimport ()\n\ntype I interface{ Do() }\n")))
+	assert.False(t, canFastFormat([]byte("package p\n\nimport \"io\"\n\ntype I interface{ Do(context.Context) }\n")))
+	assert.False(t, canFastFormat([]byte("package p\n\nimport (\n\t\"context\"\n\t\"context\"\n)\n\ntype I interface{ Do(context.Context) }\n")))
+}
+
+func TestMethodSet(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "interface.go")
+	mapPath := func(sourcePath, dirPath string) string { return out }
+
+	_, err := MakeDirWithOptions("./testdata/case_generics", Options{PathMapper: mapPath, MethodSet: "pointer"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	output, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, strings.Replace(testGenericsCompared, "\tSecond() B\n", "", 1), string(output))
+
+	_, err = MakeDirWithOptions("./testdata/case_generics", Options{PathMapper: mapPath, MethodSet: "value"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	output, err = os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, string(output), "type PairInterface[A, B any] interface {\n\tSecond() B\n}")
+	assert.NotContains(t, string(output), "First")
+	assert.NotContains(t, string(output), "BoxInterface")
+
+	_, err = MakeDirWithOptions("./testdata/case_generics", Options{PathMapper: mapPath, MethodSet: "both"})
+	assert.EqualError(t, err, `struct2interface: unknown MethodSet "both"`)
+}
+
+func TestOTelTracer(t *testing.T) {
+	_, err := MakeDirWithOptions("./testdata/case_tracer", Options{GenOTelTracer: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := os.ReadFile("./testdata/case_tracer/interface_case_tracer.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, testTracerCompared, string(output))
+}
+
+func TestGetterNames(t *testing.T) {
+	for _, grouping := range []string{"", "alphabetical", "prefix"} {
+		out := filepath.Join(t.TempDir(), "interface.go")
+		mapPath := func(sourcePath, dirPath string) string { return out }
+
+		_, err := MakeDirWithOptions("./testdata/case_getters", Options{PathMapper: mapPath, MethodGrouping: grouping})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		output, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if grouping == "" {
+			assert.Equal(t, testGettersCompared, string(output))
+		}
+		assert.Contains(t, string(output), "\tGetName() string\n", grouping)
+		assert.Contains(t, string(output), "\tName() string\n", grouping)
+	}
+}
+
+func TestMockRegistry(t *testing.T) {
+	_, err := MakeDirWithOptions("./testdata/case_test_mock", Options{GenTestMock: true, GenMockRegistry: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := os.ReadFile("./testdata/case_test_mock/mock_registry_test.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, testMockRegistryCompared, string(output))
+
+	_, err = MakeDirWithOptions("./testdata/case_test_mock", Options{GenMockRegistry: true})
+	assert.EqualError(t, err, "struct2interface: GenMockRegistry requires GenTestMock")
+}
+
+func TestEmbedParentInterface(t *testing.T) {
+	_, err := MakeDirWithOptions("./testdata/case_embed", Options{EmbedParentInterface: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := os.ReadFile("./testdata/case_embed/interface_case_embed.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, testEmbedCompared, string(output))
+
+	_, err = MakeDirWithOptions("./testdata/case_embed", Options{EmbedParentInterface: true, GenTestMock: true})
+	assert.EqualError(t, err, "struct2interface: EmbedParentInterface cannot be combined with GenTestMock")
+	_, err = MakeDirWithOptions("./testdata/case_embed", Options{EmbedParentInterface: true, GenRetry: true})
+	assert.EqualError(t, err, "struct2interface: EmbedParentInterface cannot be combined with GenRetry")
+}
+
+func TestInterfaceTemplate(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "interface.go")
+	mapPath := func(sourcePath, dirPath string) string { return out }
+	unnamed := `{{.MethodName}}({{range $i, $p := .Params}}{{if $i}}, {{end}}{{$p.Type}}{{end}}){{range .Results}} {{.Type}}{{end}}`
+
+	_, err := MakeDirWithOptions("./testdata/case_getters", Options{PathMapper: mapPath, InterfaceTemplate: unnamed})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, strings.Replace(testGettersCompared, "SetName(name string)", "SetName(string)", 1), string(output))
+
+	_, err = MakeDirWithOptions("./testdata/case_getters", Options{PathMapper: mapPath, InterfaceTemplate: "{{.MethodName"})
+	assert.Error(t, err)
+}
+
+func TestDedupImports(t *testing.T) {
+	dir := t.TempDir()
+	for name, src := range map[string]string{
+		"a.go": "package svc\n\nimport r \"net/http\"\n\ntype S struct{}\n\nfunc (s *S) A() r.Handler { return nil }\n",
+		"b.go": "package svc\n\nimport \"net/http\"\n\nfunc (s *S) B() http.Handler { return nil }\n",
+		"c.go": "package svc\n\nimport http \"net/http\"\n\nfunc (s *S) C() http.Handler { return nil }\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := MakeDirWithOptions(dir, Options{StrictMode: true}); err != nil {
+		t.Fatal(err)
+	}
+	output, err := os.ReadFile(filepath.Join(dir, "interface_svc.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, `// Code generated by struct2interface; DO NOT EDIT.
+
+package svc
+
+import (
+	"net/http"
+	r "net/http"
+)
+
+// SInterface ...
+type SInterface interface {
+	A() r.Handler
+	B() http.Handler
+	C() http.Handler
+}
+`, string(output))
+}
+
+func TestDedupImportsAcrossFiles(t *testing.T) {
+	dir := t.TempDir()
+	for name, src := range map[string]string{
+		"a.go": "package svc\n\nimport (\n\t\"context\"\n\t_ \"embed\"\n)\n\ntype Svc struct{}\n\nfunc (s *Svc) A(ctx context.Context) {}\n",
+		"b.go": "package svc\n\nimport (\n\t\"context\"\n\t_ \"embed\"\n)\n\nfunc (s *Svc) B(ctx context.Context) {}\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := MakeDirWithOptions(dir, Options{DisableFormatting: true}); err != nil {
+		t.Fatal(err)
+	}
+	output, err := os.ReadFile(filepath.Join(dir, "interface_svc.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, strings.Count(string(output), `"context"`))
+	assert.Equal(t, 1, strings.Count(string(output), `_ "embed"`))
+}
+
+func TestDisableFormatting(t *testing.T) {
+	var outputs []string
+	for _, opts := range []Options{{DisableFormatting: true}, {NoFormatting: true}} {
+		out := filepath.Join(t.TempDir(), "interface.go")
+		opts.PathMapper = func(sourcePath, dirPath string) string { return out }
+
+		_, err := MakeDirWithOptions("./testdata/case_alias", opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		output, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		outputs = append(outputs, string(output))
+	}
+
+	assert.Contains(t, outputs[0], "import (\n)\n")
+	assert.Contains(t, outputs[0], "\nExec(query string) error\n")
+	assert.Equal(t, outputs[0], outputs[1])
+}
+
+func TestRetry(t *testing.T) {
+	_, err := MakeDirWithOptions("./testdata/case_retry", Options{GenRetry: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := os.ReadFile("./testdata/case_retry/interface_case_retry.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, testRetryCompared, string(output))
+}
+
+func TestGroupBy(t *testing.T) {
+	written, err := MakeDirWithOptions("./testdata/case_group_by", Options{GroupBy: "struct", GenCircuitBreaker: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var all string
+	for _, structName := range []string{"Reader", "Writer"} {
+		output, err := os.ReadFile("./testdata/case_group_by/interface_" + structName + ".go")
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Contains(t, string(output), "type "+structName+"Interface interface {")
+		assert.Contains(t, string(output), "type "+structName+"CircuitBreaker struct {")
+		all += string(output)
+	}
+	assert.Equal(t, 1, strings.Count(all, "type circuitState int"))
+
+	unwanted, err := filepath.Abs("./testdata/case_group_by/interface_case_group_by.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotContains(t, written, unwanted)
+	assert.Len(t, written, 2)
+
+	out := filepath.Join(t.TempDir(), "io.go")
+	_, err = MakeDirWithOptions("./testdata/case_group_by", Options{
+		GroupBy:     "custom",
+		GroupByFunc: func(structName, pkgName, dir string) string { return out },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	output, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, string(output), "type ReaderInterface interface {")
+	assert.Contains(t, string(output), "type WriterInterface interface {")
+
+	_, err = MakeDirWithOptions("./testdata/case_group_by", Options{GroupBy: "custom"})
+	assert.EqualError(t, err, `struct2interface: GroupBy "custom" requires GroupByFunc`)
+	_, err = MakeDirWithOptions("./testdata/case_group_by", Options{GroupBy: "file"})
+	assert.EqualError(t, err, `struct2interface: unknown GroupBy "file"`)
+	_, err = MakeDirWithOptions("./testdata/case_group_by", Options{GroupBy: "directory"})
+	assert.EqualError(t, err, `struct2interface: GroupBy "directory" is not supported, a file holds a single package`)
+}
+
+func TestPerStructFile(t *testing.T) {
+	written, err := MakeDirWithOptions("./testdata/case_per_struct", Options{PerStructFile: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := os.ReadFile("./testdata/case_per_struct/interface_Copier.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, string(output), "type CopierInterface interface {")
+	assert.Contains(t, string(output), `"io"`)
+	assert.NotContains(t, string(output), `"time"`)
+
+	output, err = os.ReadFile("./testdata/case_per_struct/interface_Clock.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, string(output), "type ClockInterface interface {")
+	assert.Contains(t, string(output), `"time"`)
+	assert.NotContains(t, string(output), `"io"`)
+
+	unwanted, err := filepath.Abs("./testdata/case_per_struct/interface_case_per_struct.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotContains(t, written, unwanted)
+	assert.Len(t, written, 2)
+
+	_, err = MakeDirWithOptions("./testdata/case_per_struct", Options{PerStructFile: true, GroupBy: "package"})
+	assert.EqualError(t, err, `struct2interface: PerStructFile conflicts with GroupBy "package"`)
+}
+
+func TestMakeDirFS(t *testing.T) {
+	src, err := os.ReadFile("./testdata/case_single_file/testdata.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := os.ReadFile("./testdata/case_single_file/interface_case_single_file.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := MakeDirFS(fstest.MapFS{
+		"svc/testdata.go":                   {Data: src},
+		"svc/interface_case_single_file.go": {Data: []byte("package case_single_file\n")},
+		"svc/README.md":                     {Data: []byte("# svc\n")},
+	}, ".", Options{})
+	if assert.NoError(t, err) {
+		assert.Equal(t, map[string][]byte{"svc/interface_case_single_file.go": want}, files)
+	}
+
+	_, err = MakeDirFS(fstest.MapFS{}, "missing", Options{})
+	assert.True(t, errors.Is(err, fs.ErrNotExist))
+}
+
+func TestMethodAnnotations(t *testing.T) {
+	src := []byte(`package svc
+
+type Svc struct{}
+
+// Get returns the value.
+func (s *Svc) Get() int { return 0 }
+
+func (s *Svc) Set(v int) {}
+
+type Other struct{}
+
+func (o *Other) Get() int { return 0 }
+`)
+	output, err := MakeBytes("svc.go", src, Options{MethodAnnotations: map[string][]string{
+		"Get":     {"// thread-safe: yes"},
+		"Svc.Get": {"// +build_tag:pure"},
+		"Svc.Set": {"// Set is not safe for concurrent use."},
+	}})
+	if assert.NoError(t, err) {
+		assert.Contains(t, string(output), `type SvcInterface interface {
+	// thread-safe: yes
+	// +build_tag:pure
+	// Get returns the value.
+	Get() int
+	// Set is not safe for concurrent use.
+	Set(v int)
+}`)
+		assert.Contains(t, string(output), `type OtherInterface interface {
+	// thread-safe: yes
+	Get() int
+}`)
+	}
+}
+
+func TestStripReturnNames(t *testing.T) {
+	src := []byte(`package svc
+
+type Svc struct{}
+
+func (s *Svc) None(a, b int) {}
+
+func (s *Svc) One(fn func(int) (int, error)) (err error) { return nil }
+
+func (s *Svc) Unnamed() (int, error) { return 0, nil }
+
+func (s *Svc) Named(p []byte) (n int, err error) { return 0, nil }
+
+func (s *Svc) Shared() (x, y float64) { return 0, 0 }
+`)
+	output, err := MakeBytes("svc.go", src, Options{StripReturnNames: true})
+	if assert.NoError(t, err) {
+		assert.Contains(t, string(output), `type SvcInterface interface {
+	None(a, b int)
+	One(fn func(int) (int, error)) error
+	Unnamed() (int, error)
+	Named(p []byte) (int, error)
+	Shared() (float64, float64)
+}`)
+	}
+
+	output, err = MakeBytes("svc.go", src, Options{})
+	if assert.NoError(t, err) {
+		assert.Contains(t, string(output), `type SvcInterface interface {
+	None(a, b int)
+	One(fn func(int) (int, error)) (err error)
+	Unnamed() (int, error)
+	Named(p []byte) (n int, err error)
+	Shared() (x, y float64)
+}`)
+	}
+}
+
+func TestSkipGeneratedFiles(t *testing.T) {
+	dir := t.TempDir()
+	src := []byte("package svc\n\ntype Svc struct{}\n\nfunc (s *Svc) Ping() error { return nil }\n")
+	generated, err := MakeBytes("svc.go", src, Options{GenRetry: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, isGenerated(generated))
+	assert.False(t, isGenerated(src))
+
+	for name, content := range map[string][]byte{"svc.go": src, "retry.go": generated} {
+		if err := os.WriteFile(filepath.Join(dir, name), content, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := MakeDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	output, err := os.ReadFile(filepath.Join(dir, "interface_svc.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, string(output), "type SvcInterface interface {")
+	assert.NotContains(t, string(output), "SvcWithRetryInterface")
+}
+
+func TestMergeInterfaces(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"interface_Reader.go": `package svc
+
+import "io"
+
+// ReaderInterface ...
+type ReaderInterface interface {
+	// Read reads p.
+	Read(p []byte) (int, error)
+	io.Closer
+}
+`,
+		"interface_Writer.go": `package svc
+
+import (
+	"context"
+	"io"
+)
+
+// ReaderInterface ...
+type ReaderInterface interface {
+	Read(p []byte) (int, error)
+	io.Closer
+	Reset(ctx context.Context)
+}
+
+// WriterInterface ...
+type WriterInterface interface {
+	Write(p []byte) (int, error)
+}
+
+type WriterRetry struct{}
+`,
+	}
+	var paths []string
+	for _, name := range []string{"interface_Reader.go", "interface_Writer.go"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(files[name]), 0644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+
+	output, err := MergeInterfaces(paths, Options{})
+	if assert.NoError(t, err) {
+		assert.Equal(t, `// Code generated by struct2interface; DO NOT EDIT.
+
+package svc
+
+import (
+	"context"
+	"io"
+)
+
+// ReaderInterface ...
+type ReaderInterface interface {
+	// Read reads p.
+	Read(p []byte) (int, error)
+	io.Closer
+	Reset(ctx context.Context)
+}
+
+// WriterInterface ...
+type WriterInterface interface {
+	Write(p []byte) (int, error)
+}
+`, string(output))
+	}
+
+	conflicting := filepath.Join(dir, "interface_conflict.go")
+	if err := os.WriteFile(conflicting, []byte("package svc\n\ntype WriterInterface interface {\n\tWrite(p []byte) error\n}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err = MergeInterfaces(append(paths, conflicting), Options{})
+	assert.EqualError(t, err, "struct2interface: merging "+conflicting+": method WriterInterface.Write declared as Write(p []byte) (int, error) and Write(p []byte) error")
+}
+
+func TestWellKnownInterfaces(t *testing.T) {
+	src := []byte(`package svc
+
+type Svc struct{}
+
+func (s Svc) Get() int { return 0 }
+
+func (s *Svc) Set(v int) {}
+
+func (s *Svc) String() string { return "" }
+
+func (s *Svc) Read(p []byte) (n int, err error) { return 0, nil }
+
+func (s *Svc) Close(force bool) error { return nil }
+
+// Deprecated: use Get.
+func (s Svc) Error() string { return "" }
+`)
+	output, err := MakeBytes("svc.go", src, Options{MethodSet: "value", SkipDeprecated: true})
+	if assert.NoError(t, err) {
+		assert.Contains(t, string(output), `type SvcInterface interface {
+	Get() int
+	String() string
+	Read(p []byte) (n int, err error)
+	// Deprecated: use Get.
+	Error() string
+}`)
+	}
+
+	output, err = MakeBytes("svc.go", src, Options{MethodSet: "value", SkipDeprecated: true, SkipWellKnownInterfaces: true})
+	if assert.NoError(t, err) {
+		assert.Contains(t, string(output), `type SvcInterface interface {
+	Get() int
+}`)
+	}
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "interface_svc.go")
+	if err := os.WriteFile(file, []byte("old"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.NoError(t, writeFile(file, []byte("new"), Options{}))
+	content, err := os.ReadFile(file)
+	assert.NoError(t, err)
+	assert.Equal(t, "new", string(content))
+	if info, err := os.Stat(file); assert.NoError(t, err) {
+		assert.Equal(t, os.FileMode(0644), info.Mode().Perm())
+	}
+	assert.NoError(t, writeFile(file, []byte("new"), Options{WritePermissions: 0600}))
+	if info, err := os.Stat(file); assert.NoError(t, err) {
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	}
+
+	// renaming over a directory fails and leaves no temporary file behind
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "interface_dir.go"), 0755))
+	assert.Error(t, writeFile(filepath.Join(dir, "interface_dir.go"), []byte("new"), Options{}))
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+}
+
+func TestWriteHook(t *testing.T) {
+	want, err := os.ReadFile("./testdata/case_single_file/interface_case_single_file.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	written := make(map[string][]byte)
+	_, err = MakeDirWithOptions("./testdata/case_single_file", Options{
+		PathMapper: func(sourcePath, dirPath string) string {
+			return filepath.Join(dirPath, "missing", "interface.go")
+		},
+		WriteHook: func(path string, content []byte) error {
+			written[path] = content
+			return nil
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]byte{"testdata/case_single_file/missing/interface.go": want}, written)
+	_, err = os.Stat("./testdata/case_single_file/missing")
+	assert.True(t, os.IsNotExist(err))
+
+	_, err = MakeDirWithOptions("./testdata/case_single_file", Options{
+		WriteHook: func(path string, content []byte) error { return errors.New("read-only") },
+	})
+	assert.EqualError(t, err, "struct2interface: processing testdata/case_single_file/interface_case_single_file.go: read-only")
+}
+
+func TestConstructorNote(t *testing.T) {
+	src := []byte(`package svc
+
+// Svc serves.
+type Svc struct{}
+
+func (s *Svc) Get() int { return 0 }
+
+func NewSvc() *Svc { return &Svc{} }
+
+func NewSvcFromEnv() (*Svc, error) { return nil, nil }
+
+func MustSvc() *Svc { return &Svc{} }
+
+func DefaultSvc() *Svc { return &Svc{} }
+
+type Repo[T any] struct{}
+
+func (r *Repo[T]) Get() T { var v T; return v }
+
+func NewRepo[T any]() *Repo[T] { return &Repo[T]{} }
+`)
+	output, err := MakeBytes("svc.go", src, Options{})
+	if assert.NoError(t, err) {
+		assert.Contains(t, string(output), `// SvcInterface ...
+//
+//	Svc serves.
+//
+// Use NewSvc, MustSvc or DefaultSvc to construct a value satisfying this interface.
+type SvcInterface interface {`)
+		assert.Contains(t, string(output), `// RepoInterface ...
+//
+// Use NewRepo to construct a value satisfying this interface.
+type RepoInterface[T any] interface {`)
+	}
+}
+
+func TestMaxMethodLength(t *testing.T) {
+	src := []byte(`package svc
+
+type Svc struct{}
+
+func (s *Svc) Get(id int) error { return nil }
+
+func (s *Svc) Update(id int, name, email, phone, street, city, country string, age, height int) error {
+	return nil
+}
+`)
+	output, err := MakeBytes("svc.go", src, Options{MaxMethodLength: 40, GenRetry: true})
+	if assert.NoError(t, err) {
+		assert.Contains(t, string(output), `type SvcInterface interface {
+	Get(id int) error
+	// Method Update omitted: signature exceeds max length
+}`)
+		assert.NotContains(t, string(output), "func (w *SvcWithRetry) Update(")
+	}
+}
+
+func TestHealthCheck(t *testing.T) {
+	assert.NoError(t, HealthCheck("./testdata/case_structs"))
+
+	err := HealthCheck("./testdata/missing")
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+
+	dir := t.TempDir()
+	assert.EqualError(t, HealthCheck(dir), "struct2interface: health check: no Go files in "+dir)
+
+	file := filepath.Join(dir, "bad.go")
+	assert.NoError(t, os.WriteFile(file, []byte("package bad\n\nfunc {\n"), 0644))
+	err = HealthCheck(dir)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "struct2interface: health check: parsing "+file)
+	}
+}
+
+func TestGenericReceiverTypeParams(t *testing.T) {
+	src := []byte(`package repo
+
+import "fmt"
+
+type Repo[T any, K comparable] struct{}
+
+func (r *Repo[U, _]) Get(id int) U {
+	var u U
+	return u
+}
+
+func (r Repo[K, T]) Swap(k T, v K) fmt.Stringer {
+	return nil
+}
+`)
+	output, err := MakeBytes("repo.go", src, Options{})
+	if assert.NoError(t, err) {
+		assert.Contains(t, string(output), `type RepoInterface[T any, K comparable] interface {
+	Get(id int) T
+	Swap(k K, v T) fmt.Stringer
+}`)
+	}
+}
+
+func TestBadDecl(t *testing.T) {
+	src := []byte(`package svc
+
+type Svc struct{}
+
+func (s *Svc) Foo() {}
+
+1 + 2
+
+func (s *Svc) Bar() {}
+`)
+	_, err := parseStruct(src)
+	var bad *BadDeclError
+	if assert.True(t, errors.As(err, &bad)) {
+		assert.Equal(t, 7, bad.Pos.Line)
+		assert.Equal(t, 1, bad.Pos.Column)
+	}
+}
+
+func TestBadDeclSharedFileSet(t *testing.T) {
+	src := []byte(`package svc
+
+1 + 2
+`)
+	fset := token.NewFileSet()
+	for _, name := range []string{"a.go", "b.go"} {
+		_, err := makeSource(fset, name, src, Options{})
+		var bad *BadDeclError
+		if assert.True(t, errors.As(err, &bad)) {
+			assert.Equal(t, name+":3:1", bad.Pos.String())
+		}
+	}
+
+	parsed, err := makeSource(fset, "c.go", []byte("package svc\n\ntype Svc struct{}\n\nfunc (s *Svc) Get(id int) string { return \"\" }\n"), Options{})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Get(id int) string", parsed.Methods["Svc"][0].Code)
+	}
+}
+
+func TestFormatFieldList(t *testing.T) {
+	tests := []struct {
+		name   string
+		params string
+		want   []string
+	}{
+		{"empty", "", nil},
+		{"single unnamed", "int", []string{"int"}},
+		{"single named", "id int", []string{"id int"}},
+		{"shared type", "a, b string", []string{"a, b string"}},
+		{"variadic", "format string, args ...interface{}", []string{"format string", "args ...interface{}"}},
+		{"pointer", "s *Svc", []string{"s *Svc"}},
+		{"map", "m map[string][]int", []string{"m map[string][]int"}},
+		{"slice", "b []byte", []string{"b []byte"}},
+		{"channel", "in <-chan int, out chan<- error", []string{"in <-chan int", "out chan<- error"}},
+		{"func type", "fn func(context.Context) (int, error)", []string{"fn func(context.Context) (int, error)"}},
+		{"interface type", "v interface{ String() string }", []string{"v interface{ String() string }"}},
+		{"nested generics", "m Map[string, List[Pair[int, bool]]]", []string{"m Map[string, List[Pair[int, bool]]]"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := []byte("package p\n\nfunc f(" + tt.params + ") {}\n")
+			fset := token.NewFileSet()
+			f, err := parser.ParseFile(fset, "", src, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fd := f.Decls[0].(*ast.FuncDecl)
+			assert.Equal(t, tt.want, formatFieldList(newSource(fset, f, src), fd.Type.Params))
+		})
+	}
+}
+
+func TestFormatResults(t *testing.T) {
+	tests := []struct {
+		name    string
+		results string
+		want    string
+	}{
+		{"none", "", ""},
+		{"single unnamed", "error", " error"},
+		{"parenthesized single", "(error)", " error"},
+		{"single named", "(err error)", " (err error)"},
+		{"multiple", "(int, error)", " (int, error)"},
+		{"multiple named", "(n int, err error)", " (n int, err error)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := []byte("package p\n\nfunc f() " + tt.results + " { panic(0) }\n")
+			fset := token.NewFileSet()
+			f, err := parser.ParseFile(fset, "", src, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fd := f.Decls[0].(*ast.FuncDecl)
+			assert.Equal(t, tt.want, formatResults(newSource(fset, f, src), fd.Type.Results))
+		})
+	}
+}
+
+func TestOptionsNaming(t *testing.T) {
+	dir := t.TempDir()
+	src, err := os.ReadFile("./testdata/case_alias/testdata.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "testdata.go"), src, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "broken.go"), []byte("package case_alias\n\nfunc {"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := Options{
+		InterfaceSuffix: "API",
+		OutputFileName:  func(pkgName string) string { return pkgName + "_api.go" },
+		ContinueOnError: true,
+	}
+	for i := 0; i < 2; i++ {
+		_, err = MakeDirWithOptions(dir, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	output, err := os.ReadFile(filepath.Join(dir, "case_alias_api.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, strings.Replace(testAliasCompared, "DBConnectionInterface", "DBConnectionAPI", -1), string(output))
+}
+
+func TestGoldenFiles(t *testing.T) {
+	// the inputs are not named .go so that MakeDir("./testdata") skips them
+	inputs, err := filepath.Glob("testdata/golden/*.input")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, input := range inputs {
+		t.Run(filepath.Base(input), func(t *testing.T) {
+			src, err := os.ReadFile(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			file := filepath.Join(t.TempDir(), strings.TrimSuffix(filepath.Base(input), ".input")+".go")
+			if err := os.WriteFile(file, src, 0644); err != nil {
+				t.Fatal(err)
+			}
+			parsed, err := makeFile(token.NewFileSet(), file, Options{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if parsed == nil {
+				t.Fatalf("%s has no methods", input)
+			}
+			files, err := generateFiles(map[string][]*ParsedFile{parsed.DirPath: {parsed}}, Options{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			golden := strings.TrimSuffix(input, ".input") + ".golden"
+			if *update {
+				if err := os.WriteFile(golden, files[0].content, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			expected, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, string(expected), string(files[0].content))
+		})
+	}
+}
+
+func TestProfileCPU(t *testing.T) {
+	var profile bytes.Buffer
+	_, err := MakeDirWithOptions("./testdata/case_alias", Options{ProfileCPU: &profile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotZero(t, profile.Len())
+}
+
+func TestMakeDirError(t *testing.T) {
+	dir := t.TempDir()
+	broken := filepath.Join(dir, "broken.go")
+	if err := os.WriteFile(broken, []byte("package broken\n\nfunc {"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := MakeDir(dir)
+	if assert.Error(t, err) {
+		assert.True(t, strings.HasPrefix(err.Error(), "struct2interface: processing "+broken+": "), err.Error())
+	}
+}
+
+type linesLogger []string
+
+func (l *linesLogger) Printf(format string, args ...interface{}) {
+	*l = append(*l, fmt.Sprintf(format, args...))
+}
+
+func TestLogger(t *testing.T) {
+	var lines linesLogger
+	_, err := MakeDirWithOptions("./testdata/case_single_file", Options{Logger: &lines})
+	assert.NoError(t, err)
+	if assert.Len(t, lines, 1) {
+		assert.Regexp(t, `^level=INFO msg="wrote interface file" file=testdata/case_single_file/interface_case_single_file.go duration=\S+$`, lines[0])
+	}
+}
+
+func TestSlogHandler(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "interface.go")
+	var logs bytes.Buffer
+	handler := slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})
+
+	_, err := MakeDirWithOptions("./testdata/case_alias", Options{
+		PathMapper:  func(sourcePath, dirPath string) string { return out },
+		SlogHandler: handler,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Contains(t, logs.String(), `"msg":"parsed file","file":"testdata/case_alias/testdata.go","pkg":"case_alias","methods_count":1`)
+	assert.Contains(t, logs.String(), `"msg":"wrote interface file","file":"`+out+`"`)
+}
+
+func TestMakeFile(t *testing.T) {
+	dir := t.TempDir()
+	src, err := os.ReadFile("./testdata/case_alias/testdata.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "db.go"), src, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err = MakeFile(filepath.Join(dir, "db.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	output, err := os.ReadFile(filepath.Join(dir, "interface_db.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, testAliasCompared, string(output))
+
+	assert.NoError(t, MakeFile(filepath.Join(dir, "interface_db.go")))
+	_, err = os.Stat(filepath.Join(dir, "interface_interface_db.go"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestMakeBytes(t *testing.T) {
+	src, err := os.ReadFile("./testdata/case_alias/testdata.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := MakeBytes("db.go", src, Options{})
+	assert.NoError(t, err)
+	assert.Equal(t, testAliasCompared, string(output))
+
+	output, err = MakeBytes("empty.go", []byte("package empty\n"), Options{})
+	assert.NoError(t, err)
+	assert.Nil(t, output)
+
+	_, err = MakeBytes("broken.go", []byte("package broken\n\nfunc {"), Options{})
+	assert.Error(t, err)
+
+	two := []byte("package svc\n\ntype A struct{}\n\nfunc (a *A) Run() {}\n\ntype B struct{}\n\nfunc (b *B) Run() {}\n")
+	for _, opts := range []Options{{GroupBy: "struct"}, {PerStructFile: true}, {GenContractsPackage: true}} {
+		_, err = MakeBytes("svc.go", two, opts)
+		assert.ErrorContains(t, err, "which MakeBytes cannot return")
+	}
+}
+
+func TestStrictMode(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "interface.go")
+	mapPath := func(sourcePath, dirPath string) string { return out }
+
+	for _, dir := range []string{"./testdata/case_dot_import", "./testdata/case_cgo"} {
+		_, err := MakeDirWithOptions(dir, Options{PathMapper: mapPath})
+		assert.NoError(t, err, dir)
+
+		_, err = MakeDirWithOptions(dir, Options{PathMapper: mapPath, StrictMode: true})
+		assert.Error(t, err, dir)
+	}
+}
+
+func TestInterfaceNamer(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "interface.go")
+	namer := func(structName string) string {
+		if structName == "Base" {
+			return ""
+		}
+		return "I" + structName
+	}
+
+	_, err := MakeDirWithOptions("./testdata/case_embed", Options{PathMapper: func(sourcePath, dirPath string) string { return out }, InterfaceNamer: namer})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, string(output), "// IUser ...\ntype IUser interface {")
+	assert.Contains(t, string(output), "type INamed interface {")
+	assert.NotContains(t, string(output), "Base")
+}
+
+func TestTypeDocsPanic(t *testing.T) {
+	_, err := typeDocs(nil)
+	assert.Error(t, err)
+
+	for _, src := range []string{"package empty\n", "package blank\n\nvar _ = 1\n\ntype _ struct{}\n"} {
+		parsed, err := parseStruct([]byte(src))
+		assert.NoError(t, err)
+		assert.Empty(t, parsed.Structs)
+	}
+}
+
+func TestStructFilter(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "interface.go")
+	filter := func(structName string) bool { return strings.HasPrefix(structName, "N") }
+
+	_, err := MakeDirWithOptions("./testdata/case_embed", Options{PathMapper: func(sourcePath, dirPath string) string { return out }, StructFilter: filter})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, string(output), "type NamedInterface interface {")
+	assert.NotContains(t, string(output), "BaseInterface")
+	assert.NotContains(t, string(output), "UserInterface")
+}
+
+func TestCache(t *testing.T) {
+	_, err := MakeDirWithOptions("./testdata/case_cache", Options{GenCache: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := os.ReadFile("./testdata/case_cache/interface_case_cache.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, testCacheCompared, string(output))
+
+	src, err := os.ReadFile("./testdata/case_cache/testdata.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output, err = MakeBytes("./testdata/case_cache/testdata.go", src, Options{GenCache: true, CachePrefixes: []string{"List"}})
+	if assert.NoError(t, err) {
+		assert.NotContains(t, string(output), `key := fmt.Sprintf("GetUser%#v"`)
+		assert.Contains(t, string(output), `key := fmt.Sprintf("ListNames%#v"`)
+	}
+}
+
+func TestOutputDir(t *testing.T) {
+	_, err := MakeDirWithOptions("./testdata/case_output_dir", Options{OutputDir: "./testdata/case_output_dir/gen"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := os.ReadFile("./testdata/case_output_dir/gen/interface_case_output_dir.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, testOutputDirCompared, string(output))
+
+	// the generated package below the source is not processed again
+	entries, err := os.ReadDir("./testdata/case_output_dir/gen")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, entries, 1)
+
+	src := []byte("package svc\n\ntype user struct{}\n\ntype Svc struct{}\n\nfunc (s *Svc) Get() user { return user{} }\n")
+	_, err = MakeBytes("svc.go", src, Options{OutputDir: "gen"})
+	assert.EqualError(t, err, "struct2interface: method Get: unexported type user cannot be used outside package svc")
+
+	_, err = MakeBytes("svc.go", src, Options{OutputDir: "gen", GenTestMock: true})
+	assert.EqualError(t, err, "struct2interface: OutputDir cannot be combined with GenTestMock")
+	_, err = MakeBytes("svc.go", src, Options{OutputDir: "internal/my-mocks"})
+	assert.EqualError(t, err, "struct2interface: OutputDir internal/my-mocks is not a valid package name")
+}
+
+func TestOutputDirNameClash(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a/util/util.go", "b/util/util.go"} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("package util\n\ntype Util struct{}\n\nfunc (u *Util) Run() {}\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	gen := filepath.Join(dir, "gen")
+	_, err := MakeDirWithOptions(dir, Options{OutputDir: gen})
+	assert.EqualError(t, err, fmt.Sprintf("struct2interface: %s and %s both write %s to OutputDir",
+		filepath.Join(dir, "a/util"), filepath.Join(dir, "b/util"), filepath.Join(gen, "interface_util.go")))
+	_, err = os.Stat(filepath.Join(gen, "interface_util.go"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestOutputDirMultiFile(t *testing.T) {
+	// User is declared in a file without methods
+	_, err := MakeDirWithOptions("./testdata/case_output_dir_multi", Options{OutputDir: "./testdata/case_output_dir_multi/gen"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := os.ReadFile("./testdata/case_output_dir_multi/gen/interface_case_output_dir_multi.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, testOutputDirMultiFileCompared, string(output))
+}
+
+func TestTimeoutWrapper(t *testing.T) {
+	_, err := MakeDirWithOptions("./testdata/case_timeout", Options{
+		GenTimeoutWrapper: true,
+		Timeout:           5 * time.Second,
+		MethodTimeouts: map[string]time.Duration{
+			"Ping":          250 * time.Millisecond,
+			"Client.Notify": 0,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := os.ReadFile("./testdata/case_timeout/interface_case_timeout.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, testTimeoutCompared, string(output))
+}
+
+func TestWrittenFiles(t *testing.T) {
+	want, err := filepath.Abs("./testdata/case_single_file/interface_case_single_file.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	written, err := MakeDirWithOptions("./testdata/case_single_file", Options{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{want}, written)
+
+	written, err = MakeDirWithOptions("./testdata/case_single_file", Options{
+		BeforeWrite: func(path string, content []byte) ([]byte, error) {
+			return nil, errors.New("skipped")
+		},
+	})
+	assert.Error(t, err)
+	assert.Empty(t, written)
+}
+
+func TestUnresolvedIdents(t *testing.T) {
+	dir := t.TempDir()
+	for name, src := range map[string]string{
+		"svc.go":   "package svc\n\nimport \"context\"\n\ntype Svc struct{}\n\nfunc (s *Svc) Get(ctx context.Context, id ID) (*Config, error) { return nil, nil }\n\nfunc (s *Svc) Put(v Value) [Size]byte { return [Size]byte{} }\n",
+		"types.go": "package svc\n\ntype Config struct{}\n\nconst Size = 8\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	parsed, err := parseStruct([]byte("package svc\n\ntype ID int\n\ntype Svc struct{}\n\nfunc (s *Svc) Get(id ID, v Value, name string) (*Config, error) { return nil, nil }\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Value", "Config"}, parsed.Methods["Svc"][0].unresolved)
+
+	_, err = MakeDirWithOptions(dir, Options{})
+	assert.NoError(t, err)
+	_, err = MakeDirWithOptions(dir, Options{StrictMode: true})
+	assert.EqualError(t, err, "struct2interface: method signature uses an undeclared identifier dir="+dir+" struct=Svc method=Get ident=ID")
+}
+
+func TestBuildContext(t *testing.T) {
+	var (
+		out        = filepath.Join(t.TempDir(), "interface.go")
+		mapPath    = func(sourcePath, dirPath string) string { return out }
+		duplicates []string
+		linux      = build.Default
+		windows    = build.Default
+	)
+	linux.GOOS, windows.GOOS = "linux", "windows"
+
+	_, err := MakeDirWithOptions("./testdata/case_duplicate", Options{
+		PathMapper:   mapPath,
+		BuildContext: &linux,
+		OnDuplicateMethod: func(structName, methodName, file1, file2 string) error {
+			duplicates = append(duplicates, methodName)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Empty(t, duplicates)
+	output, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, string(output), "//go:build !windows\n")
+	assert.Equal(t, 1, strings.Count(string(output), "Path() string"))
+
+	src, err := os.ReadFile("./testdata/case_build_tags/testdata.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	output, err = MakeBytes("./testdata/case_build_tags/testdata.go", src, Options{BuildContext: &windows})
+	assert.NoError(t, err)
+	assert.Nil(t, output)
+	output, err = MakeBytes("./testdata/case_build_tags/testdata.go", src, Options{BuildContext: &linux})
+	assert.NoError(t, err)
+	assert.NotNil(t, output)
+
+	// build.Default is used by default
+	ignored := []byte("//go:build ignore\n\npackage svc\n\ntype Svc struct{}\n\nfunc (s *Svc) Get() {}\n")
+	output, err = MakeBytes("svc.go", ignored, Options{})
+	assert.NoError(t, err)
+	assert.Nil(t, output)
+}
+
+func TestBaseInterface(t *testing.T) {
+	src := []byte(`package svc
+
+type Svc struct{}
+
+func (s *Svc) Get() string { return "" }
+`)
+	output, err := MakeBytes("svc/svc.go", src, Options{BaseInterface: "io.Closer"})
+	assert.NoError(t, err)
+	assert.Equal(t, `// Code generated by struct2interface; DO NOT EDIT.
+
+package svc
+
+import (
+	"io"
+)
+
+// SvcInterface ...
+type SvcInterface interface {
+	io.Closer
+	Get() string
+}
+`, string(output))
+
+	output, err = MakeBytes("svc/svc.go", src, Options{BaseInterface: "gopkg.in/yaml.v3.Marshaler", DisableFormatting: true})
+	assert.NoError(t, err)
+	assert.Contains(t, string(output), `"gopkg.in/yaml.v3"`)
+	assert.Contains(t, string(output), "yaml.Marshaler\n")
+
+	output, err = MakeBytes("svc/svc.go", src, Options{BaseInterface: "SvcInterface"})
+	assert.NoError(t, err)
+	assert.NotContains(t, string(output), "\tSvcInterface\n")
+
+	_, err = MakeBytes("svc/svc.go", src, Options{BaseInterface: "io.Closer()"})
+	assert.EqualError(t, err, "struct2interface: BaseInterface io.Closer(): not a type name")
+	_, err = MakeBytes("svc/svc.go", src, Options{BaseInterface: "io.Closer", GenCache: true})
+	assert.EqualError(t, err, "struct2interface: BaseInterface cannot be combined with GenCache")
+}
+
+func TestInterfaceExists(t *testing.T) {
+	ok, err := InterfaceExists("./testdata/case_single_file", "Method1")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = InterfaceExists("./testdata/case_single_file", "Missing")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	_, err = InterfaceExists("./testdata/missing", "Method")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestIncludeTestFiles(t *testing.T) {
+	dir := t.TempDir()
+	for name, src := range map[string]string{
+		"svc.go":       "package svc\n\ntype Svc struct{}\n\nfunc (s *Svc) Get() string { return \"\" }\n",
+		"fake_test.go": "package svc\n\ntype Fake struct{}\n\nfunc (f *Fake) Get() string { return \"\" }\n",
+		"ext_test.go":  "package svc_test\n\ntype Helper struct{}\n\nfunc (h *Helper) Run() {}\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	written, err := MakeDirWithOptions(dir, Options{})
+	assert.NoError(t, err)
+	assert.Len(t, written, 1)
+	output, err := os.ReadFile(filepath.Join(dir, "interface_svc.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotContains(t, string(output), "FakeInterface")
+
+	written, err = MakeDirWithOptions(dir, Options{IncludeTestFiles: true})
+	assert.NoError(t, err)
+	assert.Len(t, written, 3)
+	output, err = os.ReadFile(filepath.Join(dir, "interface_svc_test.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, string(output), "package svc\n")
+	assert.Contains(t, string(output), "type FakeInterface interface")
+	assert.NotContains(t, string(output), "SvcInterface")
+	output, err = os.ReadFile(filepath.Join(dir, "interface_svc_test_test.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, string(output), "package svc_test\n")
+	assert.Contains(t, string(output), "type HelperInterface interface")
+
+	// the internal test file shares the circuit breaker state of the package
+	_, err = MakeDirWithOptions(dir, Options{IncludeTestFiles: true, GenCircuitBreaker: true})
+	assert.NoError(t, err)
+	for name, want := range map[string]int{
+		"interface_svc.go":           1,
+		"interface_svc_test.go":      0,
+		"interface_svc_test_test.go": 1,
+	} {
+		output, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, want, strings.Count(string(output), "var ErrCircuitOpen"), name)
+	}
+}
+
+func TestIncludePackageDoc(t *testing.T) {
+	dir := t.TempDir()
+	src := "// Package svc serves.\npackage svc\n\ntype Svc struct{}\n\nfunc (s *Svc) Get() string { return \"\" }\n"
+	if err := os.WriteFile(filepath.Join(dir, "svc.go"), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	read := func() string {
+		output, err := os.ReadFile(filepath.Join(dir, "interface_svc.go"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(output)
+	}
+
+	_, err := MakeDirWithOptions(dir, Options{})
+	assert.NoError(t, err)
+	assert.NotContains(t, read(), "// Package svc")
+
+	_, err = MakeDirWithOptions(dir, Options{IncludePackageDoc: true})
+	assert.NoError(t, err)
+	assert.Contains(t, read(), "// Package svc serves.\npackage svc\n")
+
+	doc := "// Package svc provides the service.\n//\n// It has two paragraphs.\npackage svc\n"
+	if err := os.WriteFile(filepath.Join(dir, "doc.go"), []byte(doc), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err = MakeDirWithOptions(dir, Options{IncludePackageDoc: true})
+	assert.NoError(t, err)
+	assert.Contains(t, read(), doc)
+
+	_, err = MakeDirWithOptions(dir, Options{IncludePackageDoc: true, PackageComment: "Package svc is generated."})
+	assert.NoError(t, err)
+	assert.Contains(t, read(), "// Package svc is generated.\npackage svc\n")
+}
+
+func TestExcludeFiles(t *testing.T) {
+	src := []byte("package svc\n\ntype Svc struct{}\n\nfunc (s *Svc) Get() string { return \"\" }\n")
+	pb := []byte("package svc\n\ntype Msg struct{}\n\nfunc (m *Msg) Reset() {}\n")
+	vendored := []byte("package dep\n\ntype Dep struct{}\n\nfunc (d *Dep) Run() {}\n")
+	root := fstest.MapFS{
+		"svc/svc.go":            {Data: src},
+		"svc/msg.pb.go":         {Data: pb},
+		"svc/vendor/dep/dep.go": {Data: vendored},
+		"vendor/other/other.go": {Data: vendored},
+	}
+
+	files, err := MakeDirFS(root, ".", Options{})
+	assert.NoError(t, err)
+	assert.Len(t, files, 3)
+
+	files, err = MakeDirFS(root, ".", Options{
+		ExcludeFiles: []*regexp.Regexp{regexp.MustCompile(`\.pb\.go$`)},
+		ExcludeDirs:  []*regexp.Regexp{regexp.MustCompile(`^vendor$`)},
+	})
+	assert.NoError(t, err)
+	if assert.Len(t, files, 1) {
+		assert.NotContains(t, string(files["svc/interface_svc.go"]), "MsgInterface")
+	}
+
+	// the walked directory itself is never excluded
+	files, err = MakeDirFS(root, "vendor", Options{ExcludeDirs: []*regexp.Regexp{regexp.MustCompile(`^vendor$`)}})
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+}
+
+func TestNameDirective(t *testing.T) {
+	src := []byte(`package svc
+
+//struct2interface:name=Repository
+type repoImpl struct{}
+
+func (r *repoImpl) Get() string { return "" }
+
+//struct2interface:name=-
+type Internal struct{}
+
+func (i *Internal) Run() {}
+
+type Svc struct{}
+
+func (s *Svc) Get() string { return "" }
+
+// Cache keeps values.
+//
+// struct2interface:name Store
+type Cache struct{}
+
+func (c *Cache) Load() string { return "" }
+`)
+	output, err := MakeBytes("svc/svc.go", src, Options{InterfaceSuffix: "API"})
+	assert.NoError(t, err)
+	assert.Equal(t, `// Code generated by struct2interface; DO NOT EDIT.
+
+package svc
+
+// Repository ...
+type Repository interface {
+	Get() string
+}
+
+// SvcAPI ...
+type SvcAPI interface {
+	Get() string
+}
+
+// Store ...
+//
+//	Cache keeps values.
+type Store interface {
+	Load() string
+}
+`, string(output))
+
+	for directive, name := range map[string]string{
+		"//struct2interface:name=Not-Valid":  "Not-Valid",
+		"// struct2interface:name Not Valid": "Not Valid",
+		"//struct2interface:name":            "",
+	} {
+		_, err = MakeBytes("svc/svc.go", []byte("package svc\n\n"+directive+"\ntype Svc struct{}\n\nfunc (s *Svc) Get() {}\n"), Options{})
+		assert.EqualError(t, err, fmt.Sprintf("svc/svc.go: invalid interface name %q for Svc", name))
+	}
+}
+
+func TestEmitAssertions(t *testing.T) {
+	src := []byte(`package svc
+
+type Svc struct{}
+
+func (s *Svc) Get() string { return "" }
+
+type Box[T any] struct{}
+
+func (b Box[T]) Get() T { var v T; return v }
+`)
+	output, err := MakeBytes("svc/svc.go", src, Options{EmitAssertions: true})
+	assert.NoError(t, err)
+	assert.Equal(t, `// Code generated by struct2interface; DO NOT EDIT.
+
+package svc
+
+// SvcInterface ...
+type SvcInterface interface {
+	Get() string
+}
+
+// BoxInterface ...
+type BoxInterface[T any] interface {
+	Get() T
+}
+
+var (
+	_ SvcInterface = (*Svc)(nil)
+)
+`, string(output))
+}
+
+func TestChangeDetector(t *testing.T) {
+	_, err := MakeDirWithOptions("./testdata/case_change_detector", Options{GenChangeDetector: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := os.ReadFile("./testdata/case_change_detector/interface_case_change_detector.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, testChangeDetectorCompared, string(output))
+}
+
+func TestSkipByFilePattern(t *testing.T) {
+	src := []byte("package svc\n\ntype Svc struct{}\n\nfunc (s *Svc) Get() string { return \"\" }\n")
+	root := fstest.MapFS{
+		"svc/svc.go":                  {Data: src},
+		"svc/testdata/fixture.go":     {Data: src},
+		"svc/sub/testdata/fixture.go": {Data: src},
+		"svc/sub/testdata/deep/x.go":  {Data: src},
+	}
+
+	files, err := MakeDirFS(root, ".", Options{})
+	assert.NoError(t, err)
+	assert.Len(t, files, 4)
+
+	files, err = MakeDirFS(root, ".", Options{SkipByFilePattern: []string{"*/testdata/*"}})
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+	assert.Contains(t, files, "svc/interface_svc.go")
+
+	assert.True(t, Options{SkipByFilePattern: []string{"svc/*.go"}}.skipPath(filepath.Join("a", "svc", "svc.go")))
+	assert.False(t, Options{SkipByFilePattern: []string{"svc/*.go"}}.skipPath(filepath.Join("svc", "sub", "svc.go")))
+
+	_, err = MakeDirFS(root, ".", Options{SkipByFilePattern: []string{"["}})
+	assert.EqualError(t, err, `struct2interface: SkipByFilePattern "[": syntax error in pattern`)
+}
+
+func TestSplitStructOrder(t *testing.T) {
+	dir := t.TempDir()
+	for name, src := range map[string]string{
+		"user_service.go":       "package svc\n\ntype UserService struct{}\n\nfunc (s *UserService) Get() {}\n\nfunc (s *UserService) Put() {}\n\ntype Admin struct{}\n\nfunc (a *Admin) Grant() {}\n",
+		"user_service_extra.go": "package svc\n\ntype Audit struct{}\n\nfunc (a *Audit) Log() {}\n\nfunc (s *UserService) Delete() {}\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var outputs []string
+	for _, workers := range []int{1, 4, 1, 4} {
+		_, err := MakeDirWithOptions(dir, Options{Workers: workers, DisableFormatting: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		output, err := os.ReadFile(filepath.Join(dir, "interface_svc.go"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		outputs = append(outputs, string(output))
+	}
+	for _, output := range outputs[1:] {
+		assert.Equal(t, outputs[0], output)
+	}
+	// files in name order, structs and methods in declaration order
+	var names []string
+	for _, line := range strings.Split(outputs[0], "\n") {
+		if fields := strings.Fields(line); len(fields) > 1 && fields[0] == "type" {
+			names = append(names, fields[1])
+		} else if strings.HasSuffix(line, "()") {
+			names = append(names, strings.TrimSpace(line))
+		}
+	}
+	assert.Equal(t, []string{"UserServiceInterface", "Get()", "Put()", "Delete()", "AdminInterface", "Grant()", "AuditInterface", "Log()"}, names)
+}
+
+func TestMakeReader(t *testing.T) {
+	src, err := os.ReadFile("./testdata/case_single_file/testdata.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := MakeBytes("./testdata/case_single_file/testdata.go", src, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := MakeReader(bytes.NewReader(src), "./testdata/case_single_file/testdata.go", Options{})
+	assert.NoError(t, err)
+	assert.Equal(t, want, output)
+
+	_, err = MakeReader(iotest.ErrReader(errors.New("broken pipe")), "stdin.go", Options{})
+	assert.EqualError(t, err, "broken pipe")
+}
+
+func TestEncodingMethods(t *testing.T) {
+	src := []byte(`package svc
+
+type Svc struct{}
+
+func (s *Svc) Get() int { return 0 }
+
+func (s Svc) MarshalJSON() ([]byte, error) { return nil, nil }
+
+func (s *Svc) UnmarshalJSON(data []byte) error { return nil }
+
+func (s Svc) GobEncode() ([]byte, error) { return nil, nil }
+
+func (s *Svc) GobDecode(data []byte) error { return nil }
+
+func (s Svc) MarshalYAML() (interface{}, error) { return nil, nil }
+`)
+	output, err := MakeBytes("svc.go", src, Options{MethodSet: "pointer"})
+	if assert.NoError(t, err) {
+		assert.Contains(t, string(output), `type SvcInterface interface {
+	Get() int
+	MarshalJSON() ([]byte, error)
+	UnmarshalJSON(data []byte) error
+	GobEncode() ([]byte, error)
+	GobDecode(data []byte) error
+}`)
+	}
+
+	output, err = MakeBytes("svc.go", src, Options{MethodSet: "pointer", SkipEncodingMethods: true, SkipGobMethods: true})
+	if assert.NoError(t, err) {
+		assert.Contains(t, string(output), `type SvcInterface interface {
+	Get() int
+	UnmarshalJSON(data []byte) error
+	GobDecode(data []byte) error
+}`)
+	}
+}
+
+func TestNil(t *testing.T) {
+	t.Run("空路径", func(t *testing.T) {
+		err := MakeDir("./notfind")
+		assert.EqualError(t, err, "lstat ./notfind: no such file or directory")
+	})
+}
+
+func Benchmark_TestDir(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_ = MakeDir("./testdata")
+	}
+}
+
+// benchmarkDir is a large real-world package of the standard library.
+var benchmarkDir = filepath.Join(build.Default.GOROOT, "src", "net", "http")
+
+func benchmarkSource(b *testing.B) (string, []byte) {
+	file := filepath.Join(benchmarkDir, "server.go")
+	src, err := os.ReadFile(file)
+	if err != nil {
+		b.Skip(err)
+	}
+	return file, src
+}
+
+func BenchmarkParseStruct(b *testing.B) {
+	_, src := benchmarkSource(b)
+	b.ReportAllocs()
+	b.SetBytes(int64(len(src)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := parseStruct(src); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkMakeFile(b *testing.B) {
+	file, src := benchmarkSource(b)
+	b.ReportAllocs()
+	b.SetBytes(int64(len(src)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := makeFile(token.NewFileSet(), file, Options{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkFormatCode(b *testing.B) {
+	file, _ := benchmarkSource(b)
+	parsed, err := makeFile(token.NewFileSet(), file, Options{})
+	if err != nil {
+		b.Fatal(err)
+	}
+	files, err := generateFiles(map[string][]*ParsedFile{benchmarkDir: {parsed}}, Options{DisableFormatting: true})
+	if err != nil {
+		b.Fatal(err)
+	}
+	code := string(files[0].content)
+	b.ReportAllocs()
+	b.SetBytes(int64(len(code)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := formatCode(code); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkMakeDir(b *testing.B) {
+	benchmarkSource(b)
+	out := filepath.Join(b.TempDir(), "interface.go")
+	opts := Options{PathMapper: func(sourcePath, dirPath string) string { return out }}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := MakeDirWithOptions(benchmarkDir, opts); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkWalkDir(b *testing.B) {
+	benchmarkSource(b)
+	for _, bm := range []struct {
+		name    string
+		workers int
+	}{
+		{"Sequential", 1},
+		{"Parallel", 0},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := walkDir(benchmarkDir, Options{Workers: bm.workers}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
 	}
 }
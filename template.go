@@ -0,0 +1,35 @@
+package struct2interface
+
+import (
+	"bytes"
+	"text/template"
+)
+
+// methodTemplateData is passed to Options.InterfaceTemplate for every method.
+type methodTemplateData struct {
+	MethodName string
+	Params     []Param
+	Results    []Param
+}
+
+// parseInterfaceTemplate parses Options.InterfaceTemplate, returning nil when
+// it is not set.
+func parseInterfaceTemplate(opts Options) (*template.Template, error) {
+	if opts.InterfaceTemplate == "" {
+		return nil, nil
+	}
+	return template.New("method").Parse(opts.InterfaceTemplate)
+}
+
+// applyInterfaceTemplate renders the signature of every method with tmpl.
+func applyInterfaceTemplate(tmpl *template.Template, methods []Method) error {
+	for i, m := range methods {
+		var buf bytes.Buffer
+		err := tmpl.Execute(&buf, methodTemplateData{MethodName: m.Name, Params: m.Params, Results: m.Results})
+		if err != nil {
+			return err
+		}
+		methods[i].Code = buf.String()
+	}
+	return nil
+}
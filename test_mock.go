@@ -0,0 +1,61 @@
+package struct2interface
+
+import (
+	"fmt"
+	"strings"
+)
+
+// makeTestMock appends a <Struct>Mock implementing the interface by calling
+// an On<Method> function field per method, panicking when it is not set.
+func makeTestMock(output []string, structName string, methods []Method, opts Options) []string {
+	var (
+		iface = opts.interfaceName(structName)
+		mock  = structName + "Mock"
+	)
+
+	output = append(output,
+		"",
+		fmt.Sprintf("// %s is a %s calling the On<Method> field of each method.", mock, iface),
+		"// Calling a method whose field is nil panics.",
+		fmt.Sprintf("type %s struct {", mock),
+	)
+	for _, m := range methods {
+		decls, _ := paramList(m.Params)
+		output = append(output, fmt.Sprintf("On%s func(%s)%s", m.Name, strings.Join(decls, ", "), resultList(m.Results)))
+	}
+	output = append(output,
+		"}",
+		"",
+		fmt.Sprintf("var _ %s = (*%s)(nil)", iface, mock),
+	)
+
+	for _, m := range methods {
+		head, args := wrapperMethod(mock, m)
+		output = append(output,
+			"",
+			head,
+			fmt.Sprintf("if w.On%s == nil {", m.Name),
+			fmt.Sprintf(`panic("%s.%s called but On%s is nil")`, mock, m.Name, m.Name),
+			"}",
+			passThrough(m, fmt.Sprintf("w.On%s(%s)", m.Name, args)),
+			"}",
+		)
+	}
+	return output
+}
+
+// makeMockRegistry returns a test file registering the mock of every struct
+// under the name of its interface.
+func makeMockRegistry(pkgName string, buildConstraint string, structs []string, opts Options) []string {
+	output := makeInterfaceHead(pkgName, "", buildConstraint, "", []string{`"reflect"`})
+	output = append(output,
+		"// MockRegistry maps the name of every generated interface to the type of its mock.",
+		"var MockRegistry = make(map[string]reflect.Type)",
+		"",
+		"func init() {",
+	)
+	for _, structName := range structs {
+		output = append(output, fmt.Sprintf("MockRegistry[%q] = reflect.TypeOf((*%sMock)(nil))", opts.interfaceName(structName), structName))
+	}
+	return append(output, "}")
+}
@@ -0,0 +1,10 @@
+package case_alias
+
+// DBConnection is a connection to the database.
+//
+//struct2interface:alias=DB
+type DBConnection struct{}
+
+func (c *DBConnection) Exec(query string) error {
+	return nil
+}
@@ -0,0 +1,9 @@
+//go:build linux && amd64
+
+package case_build_constraint
+
+type Platform struct{}
+
+func (p *Platform) Name() string {
+	return ""
+}
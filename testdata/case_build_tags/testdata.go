@@ -0,0 +1,9 @@
+//go:build linux
+
+package case_build_tags
+
+type Platform struct{}
+
+func (p *Platform) Name() string {
+	return ""
+}
@@ -0,0 +1,19 @@
+package case_builder
+
+import "io"
+
+type User struct {
+	io.Reader
+	Name       string
+	age, level int
+}
+
+func (u *User) Age() int {
+	return u.age
+}
+
+type Level int
+
+func (l Level) String() string {
+	return ""
+}
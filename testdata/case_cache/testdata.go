@@ -0,0 +1,31 @@
+package case_cache
+
+import (
+	"context"
+	"io"
+)
+
+type User struct {
+	ID   int
+	Name string
+}
+
+type Store struct{}
+
+func (s *Store) GetUser(ctx context.Context, id int) (*User, error) {
+	return &User{ID: id}, nil
+}
+
+func (s *Store) ListNames(prefix string, limit int) []string {
+	return nil
+}
+
+func (s *Store) Save(ctx context.Context, u *User) error {
+	return nil
+}
+
+func (s *Store) Reset() {}
+
+func (s *Store) GetEntry(ctx context.Context, w io.Writer, key string) (string, error) {
+	return "", nil
+}
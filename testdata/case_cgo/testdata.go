@@ -0,0 +1,18 @@
+package case_cgo
+
+// #include <stdlib.h>
+import "C"
+
+import "unsafe"
+
+type Buffer struct {
+	p unsafe.Pointer
+}
+
+func (b *Buffer) Size() C.size_t {
+	return 0
+}
+
+func (b *Buffer) Ptr() unsafe.Pointer {
+	return b.p
+}
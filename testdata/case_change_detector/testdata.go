@@ -0,0 +1,24 @@
+package case_change_detector
+
+import (
+	"context"
+	"io"
+)
+
+type Config struct{}
+
+func (c *Config) Version() int {
+	return 0
+}
+
+func (c *Config) Lookup(ctx context.Context, key string) (string, bool, error) {
+	return "", false, nil
+}
+
+func (c *Config) Reload() error {
+	return nil
+}
+
+func (c *Config) WriteTo(w io.Writer) (int64, error) {
+	return 0, nil
+}
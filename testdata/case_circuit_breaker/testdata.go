@@ -0,0 +1,26 @@
+package case_circuit_breaker
+
+import (
+	"context"
+	"io"
+)
+
+type Client struct{}
+
+func (c *Client) Get(ctx context.Context, id int) (string, error) {
+	return "", nil
+}
+
+func (c *Client) Ping() error {
+	return nil
+}
+
+func (c *Client) Name() string {
+	return ""
+}
+
+func (c *Client) Reset() {}
+
+func (c *Client) WriteTo(w io.Writer) (int64, error) {
+	return 0, nil
+}
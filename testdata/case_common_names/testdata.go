@@ -0,0 +1,21 @@
+package case_common_names
+
+import "go/token"
+
+type Node struct{}
+
+func (n *Node) Pos() token.Pos { return token.NoPos }
+
+func (n *Node) End() token.Pos { return token.NoPos }
+
+func (n *Node) String() string { return "" }
+
+func (n *Node) Error() string { return "" }
+
+func (n *Node) Read(p []byte) (int, error) { return 0, nil }
+
+func (n *Node) Write(p []byte) (int, error) { return 0, nil }
+
+func (n *Node) Close() error { return nil }
+
+func (n *Node) Reset() {}
@@ -0,0 +1,13 @@
+package case_compliance
+
+type Foo struct{}
+
+func (f *Foo) Run() error {
+	return nil
+}
+
+type Bar struct{}
+
+func (b Bar) Name() string {
+	return ""
+}
@@ -0,0 +1,13 @@
+package case_contracts
+
+import "context"
+
+type Store struct{}
+
+func (s *Store) Get(ctx context.Context, key string) ([]byte, error) {
+	return nil, nil
+}
+
+type Cache struct{}
+
+func (c *Cache) Flush() {}
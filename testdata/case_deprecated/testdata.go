@@ -0,0 +1,21 @@
+package case_deprecated
+
+type Store struct{}
+
+// Get returns the value of key.
+func (s *Store) Get(key string) string {
+	return ""
+}
+
+// Fetch returns the value of key.
+//
+// Deprecated: use Get.
+func (s *Store) Fetch(key string) string {
+	return ""
+}
+
+// Deprecated: use nothing.
+type Legacy struct{}
+
+// Deprecated: removed in v2.
+func (l *Legacy) Run() {}
@@ -0,0 +1,15 @@
+package case_dispatcher
+
+import "strings"
+
+type Calc struct{}
+
+func (c *Calc) Add(a, b int) int {
+	return a + b
+}
+
+func (c *Calc) Join(sep string, parts ...string) string {
+	return strings.Join(parts, sep)
+}
+
+func (c *Calc) Reset() {}
@@ -0,0 +1,9 @@
+package case_dot_import
+
+import . "fmt"
+
+type Printer struct{}
+
+func (p *Printer) Wrap(s Stringer) Stringer {
+	return s
+}
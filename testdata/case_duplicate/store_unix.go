@@ -0,0 +1,9 @@
+//go:build !windows
+
+package case_duplicate
+
+type Store struct{}
+
+func (s *Store) Path() string {
+	return "/var/lib/store"
+}
@@ -0,0 +1,7 @@
+//go:build windows
+
+package case_duplicate
+
+func (s *Store) Path() string {
+	return `C:\store`
+}
@@ -0,0 +1,25 @@
+package case_embed
+
+import "sync"
+
+type Base struct{}
+
+func (b *Base) ID() string {
+	return ""
+}
+
+type Named struct{}
+
+func (n Named) Name() string {
+	return ""
+}
+
+type User struct {
+	*Base
+	Named
+	sync.Mutex
+}
+
+func (u *User) Email() string {
+	return ""
+}
@@ -0,0 +1,34 @@
+package case_functional_options
+
+import "time"
+
+type Config struct {
+	timeout time.Duration
+	tags    []string
+	retries int
+}
+
+func (c *Config) WithTimeout(d time.Duration) *Config {
+	c.timeout = d
+	return c
+}
+
+func (c *Config) WithTags(tags ...string) *Config {
+	c.tags = append(c.tags, tags...)
+	return c
+}
+
+func (c *Config) WithRetries(v int) *Config {
+	c.retries = v
+	return c
+}
+
+func (c *Config) Timeout() time.Duration {
+	return c.timeout
+}
+
+type Retries int
+
+func (r Retries) WithLimit(n int) Retries {
+	return r
+}
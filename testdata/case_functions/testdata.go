@@ -0,0 +1,24 @@
+package case_functions
+
+import "io"
+
+type Client struct{}
+
+func (c *Client) Close() error {
+	return nil
+}
+
+// NewClient returns a Client.
+func NewClient() *Client {
+	return &Client{}
+}
+
+func Parse(r io.Reader) (map[string]string, error) {
+	return nil, nil
+}
+
+func helper() {}
+
+func Map[T any](v []T) []T {
+	return v
+}
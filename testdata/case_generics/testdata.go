@@ -0,0 +1,23 @@
+package case_generics
+
+// Pair holds two values.
+type Pair[A, B any] struct {
+	first  A
+	second B
+}
+
+func (p *Pair[A, B]) First() A {
+	return p.first
+}
+
+func (p Pair[A, B]) Second() B {
+	return p.second
+}
+
+type Box[T any] struct {
+	value T
+}
+
+func (b *Box[T]) Get() T {
+	return b.value
+}
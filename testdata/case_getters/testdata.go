@@ -0,0 +1,17 @@
+package case_getters
+
+type Svc struct {
+	name string
+}
+
+func (s *Svc) GetName() string {
+	return s.name
+}
+
+func (s *Svc) Name() string {
+	return s.name
+}
+
+func (s *Svc) SetName(name string) {
+	s.name = name
+}
@@ -0,0 +1,13 @@
+package case_group_by
+
+type Reader struct{}
+
+func (r *Reader) Read(p []byte) (int, error) {
+	return 0, nil
+}
+
+type Writer struct{}
+
+func (w *Writer) Write(p []byte) (int, error) {
+	return 0, nil
+}
@@ -0,0 +1,13 @@
+package case_missing_import
+
+import (
+	"context"
+
+	"example.com/missing/pkg"
+)
+
+type Client struct{}
+
+func (c *Client) Fetch(ctx context.Context) pkg.Thing {
+	return pkg.Thing{}
+}
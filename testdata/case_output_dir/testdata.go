@@ -0,0 +1,21 @@
+package case_output_dir
+
+import "context"
+
+type User struct {
+	Name string
+}
+
+type Store struct{}
+
+func (s *Store) Get(ctx context.Context, id int) (*User, error) {
+	return nil, nil
+}
+
+func (s *Store) Put(users ...User) error {
+	return nil
+}
+
+func (s *Store) User(name string) User {
+	return User{Name: name}
+}
@@ -0,0 +1,7 @@
+package case_output_dir_multi
+
+type Service struct{}
+
+func (s *Service) Get(id int) (*User, error) {
+	return nil, nil
+}
@@ -0,0 +1,5 @@
+package case_output_dir_multi
+
+type User struct {
+	Name string
+}
@@ -0,0 +1,18 @@
+package case_per_struct
+
+import (
+	"io"
+	"time"
+)
+
+type Copier struct{}
+
+func (c *Copier) Copy(dst io.Writer, src io.Reader) (int64, error) {
+	return io.Copy(dst, src)
+}
+
+type Clock struct{}
+
+func (c *Clock) Now() time.Time {
+	return time.Now()
+}
@@ -0,0 +1,24 @@
+package case_retry
+
+import (
+	"context"
+	"io"
+)
+
+type Client struct{}
+
+func (c *Client) Fetch(ctx context.Context, url string) ([]byte, error) {
+	return nil, nil
+}
+
+func (c *Client) Ping() error {
+	return nil
+}
+
+func (c *Client) Name() string {
+	return ""
+}
+
+func (c *Client) WriteTo(ctx context.Context, w io.Writer, attempt int) error {
+	return nil
+}
@@ -3,6 +3,7 @@
 package case_single_file
 
 // MethodInterface ...
+//
 //	Method describes the code and documentation
 //	tied into a method
 type MethodInterface interface {
@@ -13,6 +14,7 @@ type MethodInterface interface {
 }
 
 // Method1Interface ...
+//
 //	Method1 describes the code and documentation
 //	tied into a method
 type Method1Interface interface {
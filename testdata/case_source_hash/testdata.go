@@ -0,0 +1,7 @@
+package case_source_hash
+
+type Hashed struct{}
+
+func (h *Hashed) Name() string {
+	return ""
+}
@@ -0,0 +1,13 @@
+package testdata
+
+type PackageMethod struct{}
+
+func (m *PackageMethod) Method1() string {
+	return ""
+}
+
+type PackageMethod2 struct{}
+
+func (m *PackageMethod2) Method1() string {
+	return ""
+}
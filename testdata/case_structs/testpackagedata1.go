@@ -0,0 +1,5 @@
+package testdata
+
+func (m *PackageMethod) Method2() string {
+	return ""
+}
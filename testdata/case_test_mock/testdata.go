@@ -0,0 +1,18 @@
+package case_test_mock
+
+import (
+	"context"
+	"io"
+)
+
+type Client struct{}
+
+func (c *Client) Get(ctx context.Context, id int) (string, error) {
+	return "", nil
+}
+
+func (c *Client) Log(format string, args ...interface{}) {}
+
+func (c *Client) WriteTo(w io.Writer) (int64, error) {
+	return 0, nil
+}
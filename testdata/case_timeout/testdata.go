@@ -0,0 +1,26 @@
+package case_timeout
+
+import (
+	"context"
+	"io"
+)
+
+type Client struct{}
+
+func (c *Client) Fetch(ctx context.Context, url string) ([]byte, error) {
+	return nil, nil
+}
+
+func (c *Client) Ping(_ context.Context) error {
+	return nil
+}
+
+func (c *Client) Notify(ctx context.Context, msg string) {}
+
+func (c *Client) Name() string {
+	return ""
+}
+
+func (c *Client) Send(ctx context.Context, w io.Writer, cancel bool) error {
+	return nil
+}
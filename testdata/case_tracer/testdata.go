@@ -0,0 +1,22 @@
+package case_tracer
+
+import (
+	"context"
+	"io"
+)
+
+type Repository struct{}
+
+func (r *Repository) Find(ctx context.Context, id int64, name string) (string, error) {
+	return "", nil
+}
+
+func (r *Repository) Save(ctx context.Context, out io.Writer) error {
+	return nil
+}
+
+func (r *Repository) Close() {}
+
+func (r *Repository) WriteTo(ctx context.Context, w io.Writer, span int) error {
+	return nil
+}
@@ -0,0 +1,30 @@
+package case_views
+
+// Store persists users.
+//
+//struct2interface:output-mock=mocks
+//struct2interface:output-iface=contracts
+type Store struct{}
+
+// Get returns the user with the given id.
+func (s *Store) Get(id int) string {
+	return ""
+}
+
+// Find returns the user with the given name.
+func (s *Store) Find(name string) (*User, error) {
+	return nil, nil
+}
+
+// Reset drops every user.
+//
+//struct2interface:only=mock
+func (s *Store) Reset() {}
+
+type Plain struct{}
+
+func (p *Plain) Name() string {
+	return ""
+}
+
+type User struct{}
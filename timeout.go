@@ -0,0 +1,71 @@
+package struct2interface
+
+import (
+	"fmt"
+	"time"
+)
+
+// makeTimeoutWrapper appends a <Struct>WithTimeout wrapping the interface.
+// Methods taking a context.Context first call the wrapped one with a context
+// bounded by their timeout.
+func makeTimeoutWrapper(output []string, structName string, methods []Method, opts Options) []string {
+	var (
+		iface   = opts.interfaceName(structName)
+		wrapper = structName + "WithTimeout"
+	)
+
+	output = append(output,
+		"",
+		fmt.Sprintf("// %s wraps a %s, bounding the context of its methods with a", wrapper, iface),
+		"// timeout.",
+		fmt.Sprintf("type %s struct {", wrapper),
+		fmt.Sprintf("impl %s", iface),
+		"}",
+		"",
+		fmt.Sprintf("var _ %s = (*%s)(nil)", iface, wrapper),
+		"",
+		fmt.Sprintf("// New%s returns impl with its method timeouts.", wrapper),
+		fmt.Sprintf("func New%s(impl %s) *%s {", wrapper, iface, wrapper),
+		fmt.Sprintf("return &%s{impl: impl}", wrapper),
+		"}",
+	)
+
+	for _, m := range methods {
+		head, args := wrapperMethod(wrapper, m, "cancel")
+		call := fmt.Sprintf("w.impl.%s(%s)", m.Name, args)
+		output = append(output, "", head)
+		timeout := opts.methodTimeout(structName, m.Name)
+		if len(m.Params) == 0 || m.Params[0].Type != "context.Context" || timeout <= 0 {
+			output = append(output, passThrough(m, call), "}")
+			continue
+		}
+
+		_, names := paramList(m.Params, "cancel")
+		output = append(output,
+			fmt.Sprintf("%s, cancel := context.WithTimeout(%s, %s)", names[0], names[0], durationExpr(timeout)),
+			"defer cancel()",
+			passThrough(m, call),
+			"}",
+		)
+	}
+	return output
+}
+
+// durationExpr renders d as Go code, in the largest unit dividing it.
+func durationExpr(d time.Duration) string {
+	for _, unit := range []struct {
+		name string
+		d    time.Duration
+	}{
+		{"Hour", time.Hour},
+		{"Minute", time.Minute},
+		{"Second", time.Second},
+		{"Millisecond", time.Millisecond},
+		{"Microsecond", time.Microsecond},
+	} {
+		if d%unit.d == 0 {
+			return fmt.Sprintf("%d*time.%s", d/unit.d, unit.name)
+		}
+	}
+	return fmt.Sprintf("time.Duration(%d)", d)
+}
@@ -0,0 +1,94 @@
+package struct2interface
+
+import (
+	"fmt"
+	"strings"
+)
+
+// tracerImports are the OpenTelemetry packages used by the traced wrappers.
+var tracerImports = []string{
+	`"go.opentelemetry.io/otel/attribute"`,
+	`"go.opentelemetry.io/otel/trace"`,
+}
+
+// spanAttributes maps the simple parameter types recorded as span attributes
+// to their attribute constructor.
+var spanAttributes = map[string]string{
+	"string":  "attribute.String",
+	"bool":    "attribute.Bool",
+	"int":     "attribute.Int",
+	"int64":   "attribute.Int64",
+	"float64": "attribute.Float64",
+}
+
+// makeTracer appends a <Struct>Traced wrapping the interface in an
+// OpenTelemetry span per method call.
+func makeTracer(output []string, structName string, methods []Method, opts Options) []string {
+	var (
+		iface  = opts.interfaceName(structName)
+		traced = structName + "Traced"
+	)
+
+	output = append(output,
+		"",
+		fmt.Sprintf("// %s wraps a %s in an OpenTelemetry span per call.", traced, iface),
+		"// Simple parameters become span attributes and returned errors are recorded",
+		"// on the span.",
+		fmt.Sprintf("type %s struct {", traced),
+		fmt.Sprintf("impl %s", iface),
+		"tracer trace.Tracer",
+		"}",
+		"",
+		fmt.Sprintf("var _ %s = (*%s)(nil)", iface, traced),
+		"",
+		fmt.Sprintf("// New%s returns impl traced by tracer.", traced),
+		fmt.Sprintf("func New%s(impl %s, tracer trace.Tracer) *%s {", traced, iface, traced),
+		fmt.Sprintf("return &%s{impl: impl, tracer: tracer}", traced),
+		"}",
+	)
+
+	for _, m := range methods {
+		head, args := wrapperMethod(traced, m, "span")
+		_, names := paramList(m.Params, "span")
+		span := fmt.Sprintf("w.tracer.Start(context.Background(), %q)", structName+"."+m.Name)
+		if len(m.Params) > 0 && m.Params[0].Type == "context.Context" {
+			span = fmt.Sprintf("%s, span := w.tracer.Start(%s, %q)", names[0], names[0], structName+"."+m.Name)
+		} else {
+			span = "_, span := " + span
+		}
+		output = append(output, "", head, span, "defer span.End()")
+
+		var attrs []string
+		for i, p := range m.Params {
+			if attr, ok := spanAttributes[p.Type]; ok {
+				key := p.Name
+				if key == "" || key == "_" {
+					key = names[i]
+				}
+				attrs = append(attrs, fmt.Sprintf("%s(%q, %s)", attr, key, names[i]))
+			}
+		}
+		if len(attrs) > 0 {
+			output = append(output, fmt.Sprintf("span.SetAttributes(%s)", strings.Join(attrs, ", ")))
+		}
+
+		call := fmt.Sprintf("w.impl.%s(%s)", m.Name, args)
+		if !returnsError(m) {
+			output = append(output, passThrough(m, call), "}")
+			continue
+		}
+		var (
+			results = resultVars(m.Results)
+			err     = results[len(results)-1]
+		)
+		output = append(output,
+			fmt.Sprintf("%s := %s", strings.Join(results, ", "), call),
+			fmt.Sprintf("if %s != nil {", err),
+			fmt.Sprintf("span.RecordError(%s)", err),
+			"}",
+			fmt.Sprintf("return %s", strings.Join(results, ", ")),
+			"}",
+		)
+	}
+	return output
+}
@@ -0,0 +1,141 @@
+package struct2interface
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// unresolvedIdents returns the identifiers of the signature ft that the
+// parser left in unresolved, apart from the predeclared ones and package
+// names, which checkImports covers. They are declared in another file of the
+// package, come from a dot import or are undefined.
+func unresolvedIdents(ft *ast.FuncType, unresolved map[*ast.Ident]bool) []string {
+	var (
+		idents []string
+		seen   = make(map[string]bool)
+	)
+	ast.Inspect(ft, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.SelectorExpr:
+			// pkg.Type
+			return false
+		case *ast.Ident:
+			if unresolved[n] && !seen[n.Name] && types.Universe.Lookup(n.Name) == nil {
+				seen[n.Name] = true
+				idents = append(idents, n.Name)
+			}
+		}
+		return true
+	})
+	return idents
+}
+
+// packageDecls returns the names declared at package level by the Go files
+// of dir that skipFile keeps.
+func packageDecls(dir string) (map[string]bool, error) {
+	names := make(map[string]bool)
+	err := walkPackageDecls(dir, func(name string, _ bool) {
+		names[name] = true
+	})
+	return names, err
+}
+
+// packageTypes returns the types declared at package level by the Go files
+// of dir that skipFile keeps.
+func packageTypes(dir string) (map[string]bool, error) {
+	names := make(map[string]bool)
+	err := walkPackageDecls(dir, func(name string, isType bool) {
+		if isType {
+			names[name] = true
+		}
+	})
+	return names, err
+}
+
+// walkPackageDecls calls fn with every name declared at package level by
+// the Go files of dir that skipFile keeps, and whether it is a type.
+func walkPackageDecls(dir string, fn func(name string, isType bool)) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	fset := token.NewFileSet()
+	for _, e := range entries {
+		if e.IsDir() || skipFile(e.Name()) {
+			continue
+		}
+		a, err := parser.ParseFile(fset, filepath.Join(dir, e.Name()), nil, parser.SkipObjectResolution)
+		if err != nil {
+			return err
+		}
+		for _, d := range a.Decls {
+			switch d := d.(type) {
+			case *ast.GenDecl:
+				for _, spec := range d.Specs {
+					switch spec := spec.(type) {
+					case *ast.TypeSpec:
+						fn(spec.Name.Name, true)
+					case *ast.ValueSpec:
+						for _, n := range spec.Names {
+							fn(n.Name, false)
+						}
+					}
+				}
+			case *ast.FuncDecl:
+				if d.Recv == nil {
+					fn(d.Name.Name, false)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// checkUnresolved warns about the identifiers of the method signatures of
+// structs that are neither imported nor declared in the package in dir.
+// Nothing is checked when the package has dot imports, whose identifiers
+// cannot be told apart, or when dir cannot be read, e.g. for MakeDirFS.
+func checkUnresolved(opts Options, dir string, imports []string, structs map[string][]Method) error {
+	for _, i := range imports {
+		if strings.HasPrefix(i, ". ") {
+			return nil
+		}
+	}
+	var names []string
+	for structName, methods := range structs {
+		for _, m := range methods {
+			if len(m.unresolved) > 0 {
+				names = append(names, structName)
+				break
+			}
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	declared, err := packageDecls(dir)
+	if err != nil {
+		opts.logger().Debug("skipping unresolved identifiers check", "dir", dir, "error", err)
+		return nil
+	}
+	sort.Strings(names)
+	for _, structName := range names {
+		for _, m := range structs[structName] {
+			for _, ident := range m.unresolved {
+				if declared[ident] {
+					continue
+				}
+				if err := opts.warn("method signature uses an undeclared identifier", "dir", dir, "struct", structName, "method", m.Name, "ident", ident); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
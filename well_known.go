@@ -0,0 +1,44 @@
+package struct2interface
+
+// wellKnownMethods are the signatures of the methods of fmt.Stringer, error,
+// io.Reader, io.Writer and io.Closer, as parameter and result types.
+var wellKnownMethods = map[string][2][]string{
+	"String": {nil, {"string"}},
+	"Error":  {nil, {"string"}},
+	"Read":   {{"[]byte"}, {"int", "error"}},
+	"Write":  {{"[]byte"}, {"int", "error"}},
+	"Close":  {nil, {"error"}},
+}
+
+// jsonMethods are the signatures of the methods of json.Marshaler and
+// json.Unmarshaler.
+var jsonMethods = map[string][2][]string{
+	"MarshalJSON":   {nil, {"[]byte", "error"}},
+	"UnmarshalJSON": {{"[]byte"}, {"error"}},
+}
+
+// gobMethods are the signatures of the methods of gob.GobEncoder and
+// gob.GobDecoder.
+var gobMethods = map[string][2][]string{
+	"GobEncode": {nil, {"[]byte", "error"}},
+	"GobDecode": {{"[]byte"}, {"error"}},
+}
+
+// hasSignature reports whether m has the signature of its name in
+// signatures.
+func hasSignature(signatures map[string][2][]string, m Method) bool {
+	signature, ok := signatures[m.Name]
+	return ok && sameTypes(m.Params, signature[0]) && sameTypes(m.Results, signature[1])
+}
+
+func sameTypes(params []Param, types []string) bool {
+	if len(params) != len(types) {
+		return false
+	}
+	for i, p := range params {
+		if p.Type != types[i] {
+			return false
+		}
+	}
+	return true
+}
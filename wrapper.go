@@ -0,0 +1,99 @@
+package struct2interface
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// wrapperIdent reports whether a parameter named name would collide with
+// the w receiver or the r<i> results of the generated wrapper methods, or
+// with one of the other identifiers in reserved.
+func wrapperIdent(name string, reserved []string) bool {
+	if name == "w" {
+		return true
+	}
+	for _, r := range reserved {
+		if name == r {
+			return true
+		}
+	}
+	if len(name) < 2 || name[0] != 'r' {
+		return false
+	}
+	_, err := strconv.Atoi(name[1:])
+	return err == nil
+}
+
+// paramList names every parameter, falling back to p<i> for unnamed and
+// blank ones and for those colliding with identifiers of the wrapper
+// methods (see wrapperIdent), and returns the declarations together with
+// the arguments forwarding them to another call.
+func paramList(params []Param, reserved ...string) (decls []string, args []string) {
+	taken := make(map[string]bool, len(params))
+	for _, p := range params {
+		taken[p.Name] = true
+	}
+	for i, p := range params {
+		name := p.Name
+		if name == "" || name == "_" || wrapperIdent(name, reserved) {
+			name = fmt.Sprintf("p%d", i)
+			for taken[name] {
+				name += "_"
+			}
+			taken[name] = true
+		}
+		decls = append(decls, name+" "+p.Type)
+		if strings.HasPrefix(p.Type, "...") {
+			name += "..."
+		}
+		args = append(args, name)
+	}
+	return decls, args
+}
+
+// resultList renders the result types of a method without their names.
+func resultList(results []Param) string {
+	switch len(results) {
+	case 0:
+		return ""
+	case 1:
+		return " " + results[0].Type
+	}
+	types := make([]string, len(results))
+	for i, r := range results {
+		types[i] = r.Type
+	}
+	return " (" + strings.Join(types, ", ") + ")"
+}
+
+// resultVars returns r0, r1, ... for assigning the results of a call.
+func resultVars(results []Param) []string {
+	vars := make([]string, len(results))
+	for i := range results {
+		vars[i] = fmt.Sprintf("r%d", i)
+	}
+	return vars
+}
+
+// returnsError reports whether the last result of m is an error.
+func returnsError(m Method) bool {
+	return len(m.Results) > 0 && m.Results[len(m.Results)-1].Type == "error"
+}
+
+// wrapperMethod returns the first line of a method of wrapper forwarding to
+// m, together with the arguments of the forwarded call. reserved are the
+// identifiers the method body declares, see paramList.
+func wrapperMethod(wrapper string, m Method, reserved ...string) (string, string) {
+	decls, args := paramList(m.Params, reserved...)
+	return fmt.Sprintf("func (w *%s) %s(%s)%s {", wrapper, m.Name, strings.Join(decls, ", "), resultList(m.Results)),
+		strings.Join(args, ", ")
+}
+
+// passThrough returns the body of a wrapper method that only forwards call.
+func passThrough(m Method, call string) string {
+	if len(m.Results) == 0 {
+		return call
+	}
+	return "return " + call
+}